@@ -2,9 +2,12 @@ package cmd
 
 import (
 	"fmt"
+	"kver/internal/paths"
 	"kver/internal/plugin"
+	"kver/internal/resolve"
+	"kver/internal/state"
 	"os"
-	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/spf13/cobra"
@@ -22,46 +25,31 @@ var activateCmd = &cobra.Command{
 			for lang := range plugin.All() {
 				langs = append(langs, lang)
 			}
+			sort.Strings(langs)
 		}
 		cwd, _ := os.Getwd()
-		localKver := filepath.Join(cwd, ".kver")
-		localMap := map[string]string{}
-		if data, err := os.ReadFile(localKver); err == nil {
-			for _, line := range strings.Split(string(data), "\n") {
-				if strings.Contains(line, "=") {
-					parts := strings.SplitN(line, "=", 2)
-					lang := strings.TrimSpace(parts[0])
-					ver := strings.TrimSpace(parts[1])
-					localMap[lang] = ver
-				}
-			}
-		}
-		home, _ := os.UserHomeDir()
-		globalDir := filepath.Join(home, ".kver", "versions")
 		for _, lang := range langs {
-			ver := ""
-			if v, ok := localMap[lang]; ok {
-				ver = v
-			} else {
-				link := filepath.Join(globalDir, lang)
-				if dest, err := os.Readlink(link); err == nil {
-					ver = filepath.Base(dest)
-				}
+			p, ok := plugin.Get(lang)
+			if !ok {
+				continue
+			}
+			r, ok := resolve.Current(lang, cwd)
+			if !ok || !r.Installed {
+				continue
 			}
-			if ver != "" {
-				p, ok := plugin.Get(lang)
-				if ok {
-					if shell, ok := p.(interface{ ActivateShell(version string) string }); ok {
-						fmt.Print(shell.ActivateShell(ver))
-					} else {
-						// 默认输出 PATH 方案
-						home, _ := os.UserHomeDir()
-						bin := filepath.Join(home, ".kver", "languages", lang, ver, "bin")
-						fmt.Printf("export PATH=\"%s:$PATH\"\n", bin)
-					}
+			fmt.Print(state.ActivateShell(lang, r.Version))
+			// 与 shim 执行时一致，如版本来自 go.mod 时导出 GOTOOLCHAIN=local
+			if sp, ok := p.(plugin.SourceEnvProvider); ok {
+				for _, kv := range sp.SourceEnv(r.Version, r.Requested, r.Source) {
+					name, value, _ := strings.Cut(kv, "=")
+					fmt.Printf("export %s=\"%s\"\n", name, value)
 				}
 			}
 		}
+		// shim 放在 PATH 最前，cd 到其他项目后无需重新 activate 也能切换版本
+		if fi, err := os.Stat(paths.Shims()); err == nil && fi.IsDir() {
+			fmt.Printf("export PATH=\"%s:$PATH\"\n", paths.Shims())
+		}
 	},
 }
 
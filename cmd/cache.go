@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"fmt"
+	"kver/internal/cache"
+	"kver/internal/progress"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage the download cache",
+}
+
+var cacheListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List cached downloads",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		entries, err := cache.List()
+		if err != nil {
+			fmt.Printf("[kver] Cache list failed: %v\n", err)
+			os.Exit(1)
+		}
+		var total int64
+		for _, e := range entries {
+			fmt.Printf("%10s  %s  %s\n", progress.FormatBytes(e.Size), e.LastUsed.Format("2006-01-02"), e.URL)
+			total += e.Size
+		}
+		fmt.Printf("[kver] %d cached download(s), %s total\n", len(entries), progress.FormatBytes(total))
+	},
+}
+
+var cacheCleanCmd = &cobra.Command{
+	Use:   "clean",
+	Short: "Remove all cached downloads",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := cache.Clean(); err != nil {
+			fmt.Printf("[kver] Cache clean failed: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println("[kver] Download cache cleaned.")
+	},
+}
+
+var cachePruneOlderThan string
+
+var cachePruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Remove cached downloads not used recently",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		age, err := parseAge(cachePruneOlderThan)
+		if err != nil {
+			fmt.Printf("[kver] Invalid --older-than: %v\n", err)
+			os.Exit(1)
+		}
+		removed, err := cache.Prune(age)
+		for _, e := range removed {
+			fmt.Printf("[kver] Removed %s\n", e.URL)
+		}
+		if err != nil {
+			fmt.Printf("[kver] Cache prune failed: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("[kver] Pruned %d cached download(s).\n", len(removed))
+	},
+}
+
+// parseAge 解析时长，除 time.ParseDuration 的格式外还支持天数，如 30d
+func parseAge(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, err
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(s)
+}
+
+func init() {
+	cachePruneCmd.Flags().StringVar(&cachePruneOlderThan, "older-than", "30d", "Remove entries not used within this duration (e.g. 30d, 72h)")
+	cacheCmd.AddCommand(cacheListCmd, cacheCleanCmd, cachePruneCmd)
+	rootCmd.AddCommand(cacheCmd)
+}
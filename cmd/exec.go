@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"kver/internal/plugin"
+	"kver/internal/progress"
+	"kver/internal/shim"
+	"os"
+	"os/exec"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+
+	"github.com/spf13/cobra"
+)
+
+var execCmd = &cobra.Command{
+	Use:                "exec <cmd> [args...]",
+	Short:              "Run a command with the version active in the current directory",
+	Args:               cobra.MinimumNArgs(1),
+	DisableFlagParsing: true,
+	Run: func(cmd *cobra.Command, args []string) {
+		cwd, _ := os.Getwd()
+		target, err := shim.Find(args[0], cwd)
+		var missing *shim.NotInstalledError
+		if errors.As(err, &missing) {
+			target, err = offerInstall(missing, args[0], cwd)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[kver] %v\n", err)
+			os.Exit(127)
+		}
+		before := shim.Executables(target.Lang, target.Version)
+
+		c := exec.Command(target.Path, args[1:]...)
+		c.Stdin = os.Stdin
+		c.Stdout = os.Stdout
+		c.Stderr = os.Stderr
+		c.Env = shim.Environ(target)
+		// 终端的 Ctrl-C 会同时发给子进程，这里只负责转发 SIGTERM 等信号
+		sigs := make(chan os.Signal, 1)
+		signal.Notify(sigs, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+		if err := c.Start(); err != nil {
+			fmt.Fprintf(os.Stderr, "[kver] %v\n", err)
+			os.Exit(126)
+		}
+		go func() {
+			for s := range sigs {
+				if s != os.Interrupt {
+					c.Process.Signal(s)
+				}
+			}
+		}()
+		err = c.Wait()
+		signal.Stop(sigs)
+
+		// npm install -g、gem install、pip install 等可能新增可执行文件
+		if !slices.Equal(before, shim.Executables(target.Lang, target.Version)) {
+			if err := shim.Reshim(); err != nil {
+				fmt.Fprintf(os.Stderr, "[kver] Reshim failed: %v\n", err)
+			}
+		}
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			if ws, ok := exitErr.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+				os.Exit(128 + int(ws.Signal()))
+			}
+			os.Exit(exitErr.ExitCode())
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[kver] %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// offerInstall 在项目要求的版本未安装时给出可安装的最佳匹配，
+// 终端中交互询问是否立即安装，安装后重新查找命令
+func offerInstall(missing *shim.NotInstalledError, name, cwd string) (*shim.Target, error) {
+	r := missing.Result
+	p, _ := plugin.Get(r.Lang)
+	best, err := plugin.ResolveRemote(p, r.Requested)
+	if err != nil {
+		return nil, fmt.Errorf("%v\n[kver] %v", missing, err)
+	}
+	if !progress.IsTerminal(os.Stdin) || !progress.IsTerminal(os.Stderr) {
+		return nil, fmt.Errorf("%v\n[kver] Run `kver install %s %s` to install the best match", missing, r.Lang, best)
+	}
+	fmt.Fprintf(os.Stderr, "[kver] %v\n[kver] Install %s %s now? [y/N] ", missing, r.Lang, best)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	if a := strings.ToLower(strings.TrimSpace(answer)); a != "y" && a != "yes" {
+		return nil, fmt.Errorf("%s %s not installed", r.Lang, r.Requested)
+	}
+	if err := installLocked(p, r.Lang, best); err != nil {
+		return nil, fmt.Errorf("install failed: %w", err)
+	}
+	if err := shim.Reshim(); err != nil {
+		fmt.Fprintf(os.Stderr, "[kver] Reshim failed: %v\n", err)
+	}
+	return shim.Find(name, cwd)
+}
+
+func init() {
+	rootCmd.AddCommand(execCmd)
+}
@@ -3,23 +3,48 @@ package cmd
 import (
 	"fmt"
 	"kver/internal/plugin"
+	"kver/internal/state"
 	"os"
 
 	"github.com/spf13/cobra"
 )
 
+var globalUnset bool
+
 var globalCmd = &cobra.Command{
-	Use:   "global <lang> <version>",
-	Short: "Set global default version for a language",
-	Args:  cobra.ExactArgs(2),
+	Use:   "global <lang> (<version> | --unset)",
+	Short: "Set or unset the global default version for a language",
+	Long: `Set the global default version for a language.
+
+With --unset, remove the global version of the language instead:
+
+  kver global nodejs 20
+  kver global nodejs --unset`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if globalUnset && len(args) != 1 {
+			return fmt.Errorf("global --unset takes only <lang>, received %d arg(s)", len(args))
+		}
+		if !globalUnset && len(args) != 2 {
+			return fmt.Errorf("global requires <lang> and <version> (or --unset), received %d arg(s)", len(args))
+		}
+		return nil
+	},
 	Run: func(cmd *cobra.Command, args []string) {
 		lang := args[0]
-		version := args[1]
 		p, ok := plugin.Get(lang)
 		if !ok {
 			fmt.Printf("[kver] Language not supported: %s\n", lang)
 			os.Exit(1)
 		}
+		if globalUnset {
+			if err := state.UnsetGlobal(lang); err != nil {
+				fmt.Printf("[kver] Global failed: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Printf("[kver] Global %s version unset.\n", lang)
+			return
+		}
+		version := resolveVersion(p, lang, args[1], false)
 		if err := p.Global(version); err != nil {
 			fmt.Printf("[kver] Global failed: %v\n", err)
 			os.Exit(1)
@@ -29,5 +54,6 @@ var globalCmd = &cobra.Command{
 }
 
 func init() {
+	globalCmd.Flags().BoolVar(&globalUnset, "unset", false, "Remove the global version of the language")
 	rootCmd.AddCommand(globalCmd)
 }
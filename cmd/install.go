@@ -2,17 +2,38 @@ package cmd
 
 import (
 	"fmt"
+	"kver/internal/buildenv"
+	"kver/internal/installer"
+	"kver/internal/lock"
 	"kver/internal/plugin"
+	"kver/internal/resolve"
+	"kver/internal/shim"
 	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
 
 	"github.com/spf13/cobra"
 )
 
+var installFrozen bool
+
 var installCmd = &cobra.Command{
-	Use:   "install <lang> <version>",
+	Use:   "install [<lang> [<version>]]",
 	Short: "Download and install a language version",
-	Args:  cobra.ExactArgs(2),
+	Long: `Download and install a language version.
+
+Without a version, install the versions pinned for the current directory
+(.kver, .tool-versions and language version files). Without any arguments,
+install every missing pinned version, in parallel where safe.`,
+	Args: cobra.MaximumNArgs(2),
 	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) < 2 {
+			installPinned(args)
+			return
+		}
 		lang := args[0]
 		version := args[1]
 		p, ok := plugin.Get(lang)
@@ -20,14 +41,212 @@ var installCmd = &cobra.Command{
 			fmt.Printf("[kver] Language not supported: %s\n", lang)
 			os.Exit(1)
 		}
-		if err := p.Install(version); err != nil {
+		checkBackend(p, lang)
+		if installFrozen && !plugin.IsConcrete(version) {
+			fmt.Printf("[kver] Refusing to resolve floating version %s %s with --frozen\n", lang, version)
+			os.Exit(1)
+		}
+		version = resolveVersion(p, lang, version, true)
+		if err := installLocked(p, lang, version); err != nil {
 			fmt.Printf("[kver] Install failed: %v\n", err)
 			os.Exit(1)
 		}
 		fmt.Printf("[kver] %s %s installed successfully.\n", lang, version)
+		if err := shim.Reshim(); err != nil {
+			fmt.Printf("[kver] Reshim failed: %v\n", err)
+		}
 	},
 }
 
 func init() {
+	installCmd.Flags().BoolVar(&installer.SkipVerify, "skip-verify", false, "Skip checksum verification of downloaded archives (unsafe)")
+	installCmd.Flags().BoolVarP(&installer.Verbose, "verbose", "v", false, "Stream configure/make output instead of writing it only to the build log")
+	installCmd.Flags().BoolVar(&buildenv.Skip, "skip-preflight", false, "Skip checking for compilers and development headers before source builds")
+	installCmd.Flags().StringArrayVar(&installer.ConfigureOpts, "configure-opt", nil, "Extra ./configure option for source builds (repeatable), e.g. --configure-opt=--enable-shared")
+	installCmd.Flags().IntVarP(&installer.Jobs, "jobs", "j", 0, "Number of parallel make jobs for source builds (default: number of CPUs)")
+	installCmd.Flags().StringVar(&plugin.Backend, "backend", "", "Install backend for languages that offer several, e.g. --backend=standalone for prebuilt Python")
+	installCmd.Flags().BoolVar(&installFrozen, "frozen", false, "Fail instead of resolving partial versions or aliases (for CI)")
 	rootCmd.AddCommand(installCmd)
 }
+
+// checkBackend 确认 --backend 是该语言支持的后端，否则退出
+func checkBackend(p plugin.Plugin, lang string) {
+	if plugin.Backend == "" {
+		return
+	}
+	bp, ok := p.(plugin.BackendProvider)
+	if !ok {
+		fmt.Printf("[kver] %s does not support --backend\n", lang)
+		os.Exit(1)
+	}
+	if !slices.Contains(bp.Backends(), plugin.Backend) {
+		fmt.Printf("[kver] Unknown %s backend %q (available: %s)\n", lang, plugin.Backend, strings.Join(bp.Backends(), ", "))
+		os.Exit(1)
+	}
+}
+
+// checkPinnedBackend 在开始安装前确认 --backend 适用于项目中支持多后端的语言，
+// 其他语言忽略该参数；没有任何语言支持时退出
+func checkPinnedBackend(pins []*pinned) {
+	if plugin.Backend == "" {
+		return
+	}
+	var langs []string
+	for _, pin := range pins {
+		p, _ := plugin.Get(pin.lang)
+		if _, ok := p.(plugin.BackendProvider); ok {
+			checkBackend(p, pin.lang)
+			langs = append(langs, pin.lang)
+		}
+	}
+	if len(langs) == 0 {
+		fmt.Println("[kver] --backend given, but none of the pinned languages supports it")
+		os.Exit(1)
+	}
+}
+
+// installLocked 在版本锁内安装。同一版本的安装和卸载互斥；
+// 等到锁时若已被其他进程装好，Install 会直接复用
+func installLocked(p plugin.Plugin, lang, version string) error {
+	l, err := lock.Acquire(lock.Install(lang, version), "install "+lang+" "+version)
+	if err != nil {
+		return err
+	}
+	defer l.Release()
+	return p.Install(version)
+}
+
+// pinned 是当前目录下一门语言的项目版本及其安装结果
+type pinned struct {
+	lang    string
+	result  *resolve.Result
+	version string
+	status  string
+	err     error
+}
+
+// installPinned 安装当前目录（或指定语言）的项目版本中尚未安装的部分，最后打印汇总
+func installPinned(args []string) {
+	cwd, _ := os.Getwd()
+	langs := []string{}
+	if len(args) == 1 {
+		p, ok := plugin.Get(args[0])
+		if !ok {
+			fmt.Printf("[kver] Language not supported: %s\n", args[0])
+			os.Exit(1)
+		}
+		checkBackend(p, args[0])
+		langs = append(langs, args[0])
+	} else {
+		for lang := range plugin.All() {
+			langs = append(langs, lang)
+		}
+		sort.Strings(langs)
+	}
+
+	var pins []*pinned
+	for _, lang := range langs {
+		if r, ok := resolve.Local(lang, cwd); ok {
+			pins = append(pins, &pinned{lang: lang, result: r})
+		}
+	}
+	if len(pins) == 0 {
+		fmt.Printf("[kver] No pinned versions found for %s\n", cwd)
+		if len(args) == 1 {
+			os.Exit(1)
+		}
+		return
+	}
+	if len(args) == 0 {
+		checkPinnedBackend(pins)
+	}
+
+	// 先串行解析版本，确保 --frozen 时在下载任何内容前失败
+	failed := false
+	var todo []*pinned
+	for _, pin := range pins {
+		r := pin.result
+		warnConflicts(r)
+		switch {
+		case installFrozen && !plugin.IsConcrete(r.Requested):
+			pin.err = fmt.Errorf("floating version not allowed with --frozen")
+		case r.Installed:
+			pin.version, pin.status = r.Version, "already installed"
+		default:
+			p, _ := plugin.Get(pin.lang)
+			pin.version, pin.err = plugin.ResolveRemote(p, r.Requested)
+			if pin.err == nil {
+				todo = append(todo, pin)
+			}
+		}
+		if pin.err != nil {
+			failed = true
+		}
+	}
+	if failed && installFrozen {
+		for _, pin := range todo {
+			pin.status = "skipped"
+		}
+		printPinned(pins, cwd)
+		os.Exit(1)
+	}
+
+	// --configure-opt 和 --jobs 不区分语言，一次安装多门语言时会被带进每个源码构建
+	if len(todo) > 1 && (len(installer.ConfigureOpts) > 0 || installer.Jobs > 0) {
+		var names []string
+		for _, pin := range todo {
+			names = append(names, pin.lang)
+		}
+		fmt.Printf("[kver] --configure-opt and --jobs apply to a single language, but %s would be installed; run kver install <lang> for each\n", strings.Join(names, ", "))
+		os.Exit(1)
+	}
+
+	// 各语言互不影响，并行安装；源码构建由 installer 串行执行
+	var wg sync.WaitGroup
+	for _, pin := range todo {
+		if pin.version != pin.result.Requested {
+			fmt.Printf("[kver] Resolved %s %s -> %s\n", pin.lang, pin.result.Requested, pin.version)
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p, _ := plugin.Get(pin.lang)
+			if pin.err = installLocked(p, pin.lang, pin.version); pin.err == nil {
+				pin.status = "installed"
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(todo) > 0 {
+		if err := shim.Reshim(); err != nil {
+			fmt.Printf("[kver] Reshim failed: %v\n", err)
+		}
+	}
+	if printPinned(pins, cwd) {
+		os.Exit(1)
+	}
+}
+
+// printPinned 打印安装汇总，返回是否有失败
+func printPinned(pins []*pinned, cwd string) bool {
+	failed := false
+	fmt.Println("\n[kver] Install summary:")
+	for _, pin := range pins {
+		ver := pin.version
+		if ver == "" {
+			ver = pin.result.Requested
+		}
+		status := pin.status
+		if pin.err != nil {
+			status = "failed: " + pin.err.Error()
+			failed = true
+		}
+		source := pin.result.File
+		if rel, err := filepath.Rel(cwd, source); err == nil {
+			source = rel
+		}
+		fmt.Printf("  %-8s %-12s %-20s (%s)\n", pin.lang, ver, status, source)
+	}
+	return failed
+}
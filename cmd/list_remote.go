@@ -3,11 +3,22 @@ package cmd
 import (
 	"fmt"
 	"kver/internal/plugin"
+	"kver/internal/version"
 	"os"
+	"sort"
+	"strings"
 
 	"github.com/spf13/cobra"
 )
 
+var (
+	listRemoteLTS      bool
+	listRemoteLong     bool
+	listRemoteSecurity bool
+	listRemoteUnstable bool
+	listRemoteBackends bool
+)
+
 var listRemoteCmd = &cobra.Command{
 	Use:   "list-remote <lang>",
 	Short: "List remote available versions of a language",
@@ -19,6 +30,24 @@ var listRemoteCmd = &cobra.Command{
 			fmt.Printf("[kver] Language not supported: %s\n", lang)
 			os.Exit(1)
 		}
+		bp, hasBackends := p.(plugin.BackendProvider)
+		if listRemoteBackends && !hasBackends {
+			fmt.Printf("[kver] %s has a single install backend; --backends is not supported\n", lang)
+			os.Exit(1)
+		}
+		if rl, ok := p.(plugin.ReleaseLister); ok {
+			listReleases(rl)
+			return
+		}
+		// 默认只列出当前后端的版本，每行一个；--long/--backends 才查询所有后端
+		if hasBackends && (listRemoteLong || listRemoteBackends) {
+			printRemoteBackends(bp)
+			return
+		}
+		if listRemoteLTS || listRemoteLong || listRemoteSecurity || listRemoteUnstable {
+			fmt.Printf("[kver] %s has no release metadata; --lts, --long, --security-only and --include-unstable are not supported\n", lang)
+			os.Exit(1)
+		}
 		versions, err := p.ListRemote()
 		if err != nil {
 			fmt.Printf("[kver] List-remote failed: %v\n", err)
@@ -30,6 +59,62 @@ var listRemoteCmd = &cobra.Command{
 	},
 }
 
+// printRemoteBackends 列出所有后端的远程版本，并标注每个版本可由哪些后端安装
+func printRemoteBackends(bp plugin.BackendProvider) {
+	backends, err := bp.RemoteBackends()
+	if err != nil {
+		fmt.Printf("[kver] List-remote failed: %v\n", err)
+		os.Exit(1)
+	}
+	versions := make([]string, 0, len(backends))
+	for v := range backends {
+		versions = append(versions, v)
+	}
+	version.Sort(versions)
+	for _, v := range versions {
+		fmt.Printf("%-12s %s\n", v, strings.Join(backends[v], ", "))
+	}
+}
+
+// listReleases 按 --lts/--security-only/--include-unstable 过滤发布信息，--long 时显示日期、LTS 代号等详情
+func listReleases(rl plugin.ReleaseLister) {
+	releases, err := rl.Releases()
+	if err != nil {
+		fmt.Printf("[kver] List-remote failed: %v\n", err)
+		os.Exit(1)
+	}
+	var shown []plugin.Release
+	for _, r := range releases {
+		if listRemoteLTS && r.LTS == "" || listRemoteSecurity && !r.Security || r.Unstable && !listRemoteUnstable {
+			continue
+		}
+		shown = append(shown, r)
+	}
+	sort.SliceStable(shown, func(i, j int) bool { return version.Compare(shown[i].Version, shown[j].Version) < 0 })
+	for _, r := range shown {
+		if !listRemoteLong {
+			fmt.Println(r.Version)
+			continue
+		}
+		lts, flag := r.LTS, ""
+		if lts == "" {
+			lts = "-"
+		}
+		switch {
+		case r.Unstable:
+			flag = "unstable"
+		case r.Security:
+			flag = "security"
+		}
+		fmt.Printf("%-12s %-10s %-10s %-8s %s\n", r.Version, r.Date, lts, flag, strings.Join(r.Extra, ", "))
+	}
+}
+
 func init() {
+	listRemoteCmd.Flags().BoolVar(&listRemoteLTS, "lts", false, "Only list LTS releases")
+	listRemoteCmd.Flags().BoolVar(&listRemoteLong, "long", false, "Show release date, LTS codename, security flag and bundled tools (for Python: the backends offering each version)")
+	listRemoteCmd.Flags().BoolVar(&listRemoteSecurity, "security-only", false, "Only list releases that contain security fixes")
+	listRemoteCmd.Flags().BoolVar(&listRemoteUnstable, "include-unstable", false, "Also list beta and release candidate versions")
+	listRemoteCmd.Flags().BoolVar(&listRemoteBackends, "backends", false, "List versions from every install backend and show which backends offer each")
 	rootCmd.AddCommand(listRemoteCmd)
 }
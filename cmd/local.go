@@ -25,6 +25,7 @@ var localCmd = &cobra.Command{
 			fmt.Printf("[kver] Language not supported: %s\n", lang)
 			os.Exit(1)
 		}
+		version = resolveVersion(p, lang, version, false)
 		if err := p.Local(version, cwd); err != nil {
 			fmt.Printf("[kver] Local failed: %v\n", err)
 			os.Exit(1)
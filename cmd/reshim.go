@@ -0,0 +1,26 @@
+package cmd
+
+import (
+	"fmt"
+	"kver/internal/shim"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var reshimCmd = &cobra.Command{
+	Use:   "reshim",
+	Short: "Regenerate shims for all installed executables",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := shim.Reshim(); err != nil {
+			fmt.Printf("[kver] Reshim failed: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println("[kver] Shims updated.")
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(reshimCmd)
+}
@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"fmt"
+	"kver/internal/plugin"
+	"kver/internal/resolve"
+	"os"
+)
+
+// resolveVersion 将用户输入的部分版本或别名解析为具体版本，失败时退出。
+// remote 为 true 时基于远程版本列表解析，否则基于已安装版本。
+// 提示信息写到 stderr，kver use 的 stdout 只输出供 eval 的 shell 片段。
+func resolveVersion(p plugin.Plugin, lang, query string, remote bool) string {
+	var (
+		resolved string
+		err      error
+	)
+	if remote {
+		resolved, err = plugin.ResolveRemote(p, query)
+	} else {
+		resolved, err = plugin.ResolveInstalled(p, query)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[kver] Resolve failed: %v\n", err)
+		os.Exit(1)
+	}
+	if resolved != query {
+		fmt.Fprintf(os.Stderr, "[kver] Resolved %s %s -> %s\n", lang, query, resolved)
+	}
+	return resolved
+}
+
+// warnConflicts 在同一项目中多个版本来源要求不一致时输出警告（写到 stderr）
+func warnConflicts(r *resolve.Result) {
+	for _, c := range resolve.Conflicts(r) {
+		fmt.Fprintf(os.Stderr, "[kver] Warning: %s %s in %s disagrees with %s from %s\n", r.Lang, c.Requested, c.Source, r.Version, r.Source)
+	}
+}
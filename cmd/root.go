@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"fmt"
+	"kver/internal/progress"
 	"os"
 
 	"github.com/spf13/cobra"
@@ -37,6 +38,7 @@ func Execute() {
 }
 
 func init() {
+	rootCmd.PersistentFlags().BoolVar(&progress.NoColor, "no-color", false, "Disable colored output (same as NO_COLOR=1)")
 	rootCmd.AddCommand(versionCmd)
 	// 这里可以添加 install/uninstall/list 等子命令
 }
@@ -2,7 +2,9 @@ package cmd
 
 import (
 	"fmt"
+	"kver/internal/lock"
 	"kver/internal/plugin"
+	"kver/internal/shim"
 	"os"
 
 	"github.com/spf13/cobra"
@@ -20,11 +22,21 @@ var uninstallCmd = &cobra.Command{
 			fmt.Printf("[kver] Language not supported: %s\n", lang)
 			os.Exit(1)
 		}
+		// 与同一版本的安装互斥
+		l, err := lock.Acquire(lock.Install(lang, version), "uninstall "+lang+" "+version)
+		if err != nil {
+			fmt.Printf("[kver] %v\n", err)
+			os.Exit(1)
+		}
+		defer l.Release()
 		if err := p.Uninstall(version); err != nil {
 			fmt.Printf("[kver] Uninstall failed: %v\n", err)
 			os.Exit(1)
 		}
 		fmt.Printf("[kver] %s %s uninstalled.\n", lang, version)
+		if err := shim.Reshim(); err != nil {
+			fmt.Printf("[kver] Reshim failed: %v\n", err)
+		}
 	},
 }
 
@@ -3,6 +3,7 @@ package cmd
 import (
 	"fmt"
 	"kver/internal/plugin"
+	"kver/internal/progress"
 	"os"
 
 	"github.com/spf13/cobra"
@@ -17,13 +18,17 @@ var useCmd = &cobra.Command{
 		version := args[1]
 		p, ok := plugin.Get(lang)
 		if !ok {
-			fmt.Printf("[kver] Language not supported: %s\n", lang)
+			fmt.Fprintf(os.Stderr, "[kver] Language not supported: %s\n", lang)
 			os.Exit(1)
 		}
+		version = resolveVersion(p, lang, version, false)
 		if err := p.Use(version); err != nil {
-			fmt.Printf("[kver] Use failed: %v\n", err)
+			fmt.Fprintf(os.Stderr, "[kver] Use failed: %v\n", err)
 			os.Exit(1)
 		}
+		if progress.IsTerminal(os.Stdout) {
+			fmt.Fprintf(os.Stderr, "[kver] To apply in the current shell run: eval \"$(kver use %s %s)\"\n", lang, version)
+		}
 	},
 }
 
@@ -0,0 +1,445 @@
+// Copyright (c) 2025 kk
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+// Package archive 安全地解压 .tar.gz、.tar.xz 和 .zip 安装包。
+// 拒绝绝对路径、.. 以及指向目标目录之外的链接，
+// 保留文件权限、修改时间、符号链接和硬链接，并支持 strip-components。
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// 支持的归档格式
+const (
+	TarGz = "tar.gz"
+	TarXz = "tar.xz"
+	Zip   = "zip"
+)
+
+// Detect 根据文件名判断归档格式
+func Detect(name string) (string, error) {
+	switch {
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		return TarGz, nil
+	case strings.HasSuffix(name, ".tar.xz"), strings.HasSuffix(name, ".txz"):
+		return TarXz, nil
+	case strings.HasSuffix(name, ".zip"):
+		return Zip, nil
+	}
+	return "", fmt.Errorf("unsupported archive: %s", name)
+}
+
+// Options 是解压选项
+type Options struct {
+	Format   string        // 归档格式，为空时按文件名判断
+	Strip    int           // 去掉的前导路径层数
+	Progress func(n int64) // 每读取 n 字节压缩数据时回调，可为空
+}
+
+// Extract 将 src 解压到 dest
+func Extract(src, dest string, o Options) error {
+	format := o.Format
+	if format == "" {
+		f, err := Detect(src)
+		if err != nil {
+			return err
+		}
+		format = f
+	}
+	if err := os.MkdirAll(dest, 0755); err != nil {
+		return err
+	}
+	root, err := filepath.Abs(dest)
+	if err != nil {
+		return err
+	}
+	if root, err = filepath.EvalSymlinks(root); err != nil {
+		return err
+	}
+	x := &extractor{root: root, strip: o.Strip, progress: o.Progress}
+	switch format {
+	case TarGz:
+		err = x.tarGz(src)
+	case TarXz:
+		err = x.tarXz(src)
+	case Zip:
+		err = x.zip(src)
+	default:
+		return fmt.Errorf("unsupported archive type: %s", format)
+	}
+	if err != nil {
+		return err
+	}
+	return x.finishDirs()
+}
+
+type dirMeta struct {
+	path  string
+	mode  fs.FileMode
+	mtime time.Time
+}
+
+type extractor struct {
+	root     string
+	strip    int
+	progress func(n int64)
+	dirs     []dirMeta // 目录权限和时间在所有文件写完后再设置，避免只读目录无法写入
+}
+
+// open 打开归档文件，读取时回调进度
+func (x *extractor) open(src string) (io.ReadCloser, error) {
+	f, err := os.Open(src)
+	if err != nil {
+		return nil, err
+	}
+	if x.progress == nil {
+		return f, nil
+	}
+	return &counter{f: f, fn: x.progress}, nil
+}
+
+type counter struct {
+	f  *os.File
+	fn func(n int64)
+}
+
+func (c *counter) Read(p []byte) (int, error) {
+	n, err := c.f.Read(p)
+	if n > 0 {
+		c.fn(int64(n))
+	}
+	return n, err
+}
+
+func (c *counter) Close() error { return c.f.Close() }
+
+func (x *extractor) tarGz(src string) error {
+	f, err := x.open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	gzr, err := gzip.NewReader(f)
+	if err != nil {
+		return err
+	}
+	defer gzr.Close()
+	return x.tar(gzr)
+}
+
+// tarXz 借助系统的 xz 解压，标准库不支持 xz
+func (x *extractor) tarXz(src string) error {
+	if _, err := exec.LookPath("xz"); err != nil {
+		return fmt.Errorf("xz is required to extract %s: %w", filepath.Base(src), err)
+	}
+	in, err := x.open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	cmd := exec.Command("xz", "-dc")
+	cmd.Stdin = in
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	terr := x.tar(out)
+	io.Copy(io.Discard, out)
+	if err := cmd.Wait(); err != nil {
+		return fmt.Errorf("xz failed: %v %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return terr
+}
+
+func (x *extractor) tar(r io.Reader) error {
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		target, ok, err := x.target(hdr.Name)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		mode := hdr.FileInfo().Mode().Perm()
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			err = x.mkdir(target, mode, hdr.ModTime)
+		case tar.TypeReg, tar.TypeRegA:
+			err = x.writeFile(target, tr, mode, hdr.ModTime)
+		case tar.TypeSymlink:
+			err = x.symlink(target, hdr.Linkname)
+		case tar.TypeLink:
+			err = x.hardlink(target, hdr.Linkname)
+		default:
+			// 设备文件、FIFO 等在语言发行包中不会出现，直接忽略
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (x *extractor) zip(src string) error {
+	zr, err := zip.OpenReader(src)
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+	for _, f := range zr.File {
+		target, ok, err := x.target(f.Name)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		mode := f.Mode()
+		switch {
+		case mode.IsDir():
+			perm := mode.Perm()
+			if perm == 0 {
+				perm = 0755
+			}
+			err = x.mkdir(target, perm, f.Modified)
+		case mode&fs.ModeSymlink != 0:
+			err = x.zipSymlink(f, target)
+		default:
+			err = x.zipFile(f, target)
+		}
+		if err != nil {
+			return err
+		}
+		if x.progress != nil {
+			x.progress(int64(f.CompressedSize64))
+		}
+	}
+	return nil
+}
+
+func (x *extractor) zipFile(f *zip.File, target string) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	mode := f.Mode().Perm()
+	if mode == 0 {
+		mode = 0644
+	}
+	return x.writeFile(target, rc, mode, f.Modified)
+}
+
+func (x *extractor) zipSymlink(f *zip.File, target string) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	link, err := io.ReadAll(io.LimitReader(rc, 4096))
+	if err != nil {
+		return err
+	}
+	return x.symlink(target, string(link))
+}
+
+// target 计算归档内路径对应的目标路径，ok 为 false 表示该条目被 strip 掉
+func (x *extractor) target(name string) (string, bool, error) {
+	rel, ok, err := x.clean(name)
+	if err != nil || !ok {
+		return "", ok, err
+	}
+	return filepath.Join(x.root, filepath.FromSlash(rel)), true, nil
+}
+
+// clean 规范化归档内路径并去掉前导层级，拒绝绝对路径和 ..
+func (x *extractor) clean(name string) (string, bool, error) {
+	name = strings.ReplaceAll(name, "\\", "/")
+	if path.IsAbs(name) || filepath.IsAbs(name) {
+		return "", false, fmt.Errorf("refusing to extract absolute path: %s", name)
+	}
+	var parts []string
+	for _, p := range strings.Split(name, "/") {
+		switch p {
+		case "", ".":
+			continue
+		case "..":
+			return "", false, fmt.Errorf("refusing to extract path outside destination: %s", name)
+		}
+		parts = append(parts, p)
+	}
+	if len(parts) <= x.strip {
+		return "", false, nil
+	}
+	return strings.Join(parts[x.strip:], "/"), true, nil
+}
+
+// checkParent 确认 target 的父目录解析符号链接后仍在解压目录内，
+// 防止先解压指向外部的链接再经由它写文件；返回解析后的父目录
+func (x *extractor) checkParent(target string) (string, error) {
+	parent := filepath.Dir(target)
+	// 先检查已存在的最深祖先，确认安全后再创建缺失的目录
+	existing := parent
+	for {
+		if _, err := os.Lstat(existing); err == nil || existing == x.root {
+			break
+		}
+		existing = filepath.Dir(existing)
+	}
+	for _, p := range []string{existing, parent} {
+		if p == parent {
+			if err := os.MkdirAll(parent, 0755); err != nil {
+				return "", err
+			}
+		}
+		real, err := filepath.EvalSymlinks(p)
+		if err != nil {
+			return "", err
+		}
+		if !within(x.root, real) {
+			return "", fmt.Errorf("refusing to extract through symlink outside destination: %s", target)
+		}
+		if p == parent {
+			return real, nil
+		}
+	}
+	return "", nil
+}
+
+func (x *extractor) mkdir(target string, mode fs.FileMode, mtime time.Time) error {
+	if _, err := x.checkParent(target); err != nil {
+		return err
+	}
+	if fi, err := os.Lstat(target); err == nil && !fi.IsDir() {
+		os.Remove(target)
+	}
+	if err := os.MkdirAll(target, 0755); err != nil {
+		return err
+	}
+	x.dirs = append(x.dirs, dirMeta{path: target, mode: mode, mtime: mtime})
+	return nil
+}
+
+func (x *extractor) writeFile(target string, r io.Reader, mode fs.FileMode, mtime time.Time) error {
+	if _, err := x.checkParent(target); err != nil {
+		return err
+	}
+	// 先删除已存在的条目，避免经由同名符号链接写到别处
+	os.Remove(target)
+	f, err := os.OpenFile(target, os.O_CREATE|os.O_EXCL|os.O_WRONLY, mode|0200)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	// OpenFile 的权限受 umask 影响，这里显式恢复
+	if err := os.Chmod(target, mode); err != nil {
+		return err
+	}
+	return os.Chtimes(target, mtime, mtime)
+}
+
+func (x *extractor) symlink(target, link string) error {
+	parent, err := x.checkParent(target)
+	if err != nil {
+		return err
+	}
+	if filepath.IsAbs(link) || path.IsAbs(link) {
+		return fmt.Errorf("refusing to create absolute symlink %s -> %s", target, link)
+	}
+	// .. 只允许出现在开头，从已解析的父目录向上；a/../b 中的 a 可能是（或之后被替换为）
+	// 符号链接，如 d/x -> .. 之后 y -> d/x/..，按字面判断会误认为仍在解压目录内
+	leading := true
+	for _, p := range strings.Split(strings.ReplaceAll(link, "\\", "/"), "/") {
+		switch p {
+		case "", ".":
+		case "..":
+			if !leading {
+				return fmt.Errorf("refusing to create symlink with .. after a path component: %s -> %s", target, link)
+			}
+		default:
+			leading = false
+		}
+	}
+	resolved := filepath.Join(parent, filepath.FromSlash(link))
+	if !within(x.root, resolved) {
+		return fmt.Errorf("refusing to create symlink outside destination: %s -> %s", target, link)
+	}
+	os.RemoveAll(target)
+	return os.Symlink(link, target)
+}
+
+func (x *extractor) hardlink(target, linkname string) error {
+	src, ok, err := x.target(linkname)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("hardlink target stripped: %s -> %s", target, linkname)
+	}
+	if _, err := x.checkParent(target); err != nil {
+		return err
+	}
+	if _, err := x.checkParent(src); err != nil {
+		return err
+	}
+	// 硬链接到符号链接会得到内容相同的符号链接，其相对目标要按新位置重新检查
+	if fi, err := os.Lstat(src); err == nil && fi.Mode()&fs.ModeSymlink != 0 {
+		link, err := os.Readlink(src)
+		if err != nil {
+			return err
+		}
+		return x.symlink(target, link)
+	}
+	os.Remove(target)
+	return os.Link(src, target)
+}
+
+// finishDirs 由深到浅恢复目录权限和修改时间
+func (x *extractor) finishDirs() error {
+	for i := len(x.dirs) - 1; i >= 0; i-- {
+		d := x.dirs[i]
+		if err := os.Chmod(d.path, d.mode); err != nil {
+			return err
+		}
+		os.Chtimes(d.path, d.mtime, d.mtime)
+	}
+	return nil
+}
+
+// within 判断 p 是否位于 root 之内（含 root 本身）
+func within(root, p string) bool {
+	rel, err := filepath.Rel(root, p)
+	return err == nil && filepath.IsLocal(rel)
+}
@@ -0,0 +1,128 @@
+// Copyright (c) 2025 kk
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package archive
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// entry 是测试归档中的一个条目：link 非空时为符号链接，以 = 开头时为硬链接
+type entry struct {
+	name string
+	body string
+	link string
+}
+
+func writeTarGz(t *testing.T, path string, entries []entry) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	for _, e := range entries {
+		hdr := &tar.Header{Name: e.name, Mode: 0644}
+		switch {
+		case strings.HasPrefix(e.link, "="):
+			hdr.Typeflag, hdr.Linkname = tar.TypeLink, e.link[1:]
+		case e.link != "":
+			hdr.Typeflag, hdr.Linkname = tar.TypeSymlink, e.link
+		case strings.HasSuffix(e.name, "/"):
+			hdr.Typeflag, hdr.Mode = tar.TypeDir, 0755
+		default:
+			hdr.Typeflag, hdr.Size = tar.TypeReg, int64(len(e.body))
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if hdr.Typeflag == tar.TypeReg {
+			if _, err := tw.Write([]byte(e.body)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExtractRejectsEscapes(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []entry
+	}{
+		{"dotdot file", []entry{{name: "../evil", body: "x"}}},
+		{"nested dotdot file", []entry{{name: "a/../../evil", body: "x"}}},
+		{"absolute file", []entry{{name: "/tmp/evil", body: "x"}}},
+		{"dotdot hardlink", []entry{{name: "h", link: "=../evil"}}},
+		{"absolute hardlink", []entry{{name: "h", link: "=/etc/passwd"}}},
+		{"absolute symlink", []entry{{name: "l", link: "/etc"}}},
+		{"dotdot symlink", []entry{{name: "l", link: "../evil"}}},
+		{"nested dotdot symlink", []entry{{name: "a/l", link: "../../evil"}}},
+		{"chained symlink", []entry{
+			{name: "d/x", link: ".."},
+			{name: "y", link: "d/x/.."},
+		}},
+		{"hardlink to symlink", []entry{
+			{name: "a/b/l", link: "../../x"},
+			{name: "l2", link: "=a/b/l"},
+		}},
+		{"chained symlink to file", []entry{
+			{name: "d/e/x", link: "../.."},
+			{name: "y", link: "d/e/x/../evil"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			src := filepath.Join(dir, "a.tar.gz")
+			dest := filepath.Join(dir, "out", "dest")
+			writeTarGz(t, src, tt.entries)
+			if err := Extract(src, dest, Options{}); err == nil {
+				t.Fatalf("Extract succeeded, want error")
+			}
+			for _, p := range []string{filepath.Join(dir, "evil"), filepath.Join(dir, "out", "evil")} {
+				if _, err := os.Lstat(p); err == nil {
+					t.Errorf("%s was created outside the destination", p)
+				}
+			}
+		})
+	}
+}
+
+func TestExtract(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "a.tar.gz")
+	dest := filepath.Join(dir, "dest")
+	writeTarGz(t, src, []entry{
+		{name: "pkg/"},
+		{name: "pkg/bin/"},
+		{name: "pkg/bin/tool", body: "hello"},
+		{name: "pkg/bin/alias", link: "tool"},
+		{name: "pkg/lib/up", link: "../bin/tool"},
+		{name: "pkg/lib/hard", link: "=pkg/bin/tool"},
+		{name: "pkg/lib/hardsym", link: "=pkg/lib/up"},
+	})
+	if err := Extract(src, dest, Options{Strip: 1}); err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []string{"bin/tool", "bin/alias", "lib/up", "lib/hard", "lib/hardsym"} {
+		data, err := os.ReadFile(filepath.Join(dest, p))
+		if err != nil || string(data) != "hello" {
+			t.Errorf("%s = %q, %v, want hello", p, data, err)
+		}
+	}
+}
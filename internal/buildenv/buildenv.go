@@ -0,0 +1,307 @@
+// Copyright (c) 2025 kk
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+// Package buildenv 在源码构建前检查编译器、make 和开发头文件，
+// 缺失时按发行版（Debian、RHEL、Alpine、Arch、macOS）给出安装命令。
+package buildenv
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// Skip 为 true 时跳过构建依赖检查（--skip-preflight）
+var Skip bool
+
+// 支持给出安装提示的发行版
+const (
+	Debian = "debian"
+	RHEL   = "rhel"
+	Alpine = "alpine"
+	Arch   = "arch"
+	MacOS  = "macos"
+)
+
+// Dep 是一个构建依赖：命令行工具或开发头文件
+type Dep struct {
+	Name     string            // 显示名称，如 OpenSSL
+	Command  string            // 需要的命令，与 Headers 二选一
+	Headers  []string          // 任一头文件可用即满足，如 openssl/ssl.h
+	Optional bool              // 缺少时只警告，对应的扩展模块不可用
+	Packages map[string]string // 各发行版提供该依赖的包名，空表示系统自带
+	DirOpt   string            // 指定库目录的 configure 选项，如 --with-openssl；brew 的 keg-only 包需要它
+}
+
+// Toolchain 是所有源码构建都需要的工具
+var Toolchain = []Dep{
+	{Name: "C compiler", Command: "cc", Packages: map[string]string{
+		Debian: "build-essential", RHEL: "gcc", Alpine: "build-base", Arch: "base-devel",
+	}},
+	{Name: "make", Command: "make", Packages: map[string]string{
+		Debian: "make", RHEL: "make", Alpine: "make", Arch: "make",
+	}},
+}
+
+// 常用库依赖
+var (
+	OpenSSL = Dep{Name: "OpenSSL", Headers: []string{"openssl/ssl.h"}, Packages: map[string]string{
+		Debian: "libssl-dev", RHEL: "openssl-devel", Alpine: "openssl-dev", Arch: "openssl", MacOS: "openssl@3",
+	}}
+	Zlib = Dep{Name: "zlib", Headers: []string{"zlib.h"}, Packages: map[string]string{
+		Debian: "zlib1g-dev", RHEL: "zlib-devel", Alpine: "zlib-dev", Arch: "zlib",
+	}}
+	Libffi = Dep{Name: "libffi", Headers: []string{"ffi.h", "ffi/ffi.h"}, Packages: map[string]string{
+		Debian: "libffi-dev", RHEL: "libffi-devel", Alpine: "libffi-dev", Arch: "libffi", MacOS: "libffi",
+	}}
+	SQLite = Dep{Name: "SQLite", Headers: []string{"sqlite3.h"}, Packages: map[string]string{
+		Debian: "libsqlite3-dev", RHEL: "sqlite-devel", Alpine: "sqlite-dev", Arch: "sqlite", MacOS: "sqlite",
+	}}
+	Readline = Dep{Name: "readline", Headers: []string{"readline/readline.h"}, Packages: map[string]string{
+		Debian: "libreadline-dev", RHEL: "readline-devel", Alpine: "readline-dev", Arch: "readline", MacOS: "readline",
+	}}
+	Bzip2 = Dep{Name: "bzip2", Headers: []string{"bzlib.h"}, Packages: map[string]string{
+		Debian: "libbz2-dev", RHEL: "bzip2-devel", Alpine: "bzip2-dev", Arch: "bzip2",
+	}}
+	XZ = Dep{Name: "xz", Headers: []string{"lzma.h"}, Packages: map[string]string{
+		Debian: "liblzma-dev", RHEL: "xz-devel", Alpine: "xz-dev", Arch: "xz", MacOS: "xz",
+	}}
+	LibYAML = Dep{Name: "libyaml", Headers: []string{"yaml.h"}, Packages: map[string]string{
+		Debian: "libyaml-dev", RHEL: "libyaml-devel", Alpine: "yaml-dev", Arch: "libyaml", MacOS: "libyaml",
+	}}
+)
+
+// IncludeFlags 返回构建选项中的 CFLAGS，以及 --with-openssl=DIR 这类指定库目录的
+// configure 选项对应的 -IDIR/include，用于检查头文件
+func IncludeFlags(cflags string, configureOpts []string) []string {
+	flags := strings.Fields(cflags)
+	for _, opt := range configureOpts {
+		name, dir, ok := strings.Cut(opt, "=")
+		if ok && strings.HasPrefix(name, "--with-") && strings.HasPrefix(dir, "/") {
+			flags = append(flags, "-I"+dir+"/include")
+		}
+	}
+	return flags
+}
+
+// Optionally 返回标记为可选的依赖副本
+func Optionally(d Dep) Dep {
+	d.Optional = true
+	return d
+}
+
+// WithDirOpt 返回指定了库目录 configure 选项的依赖副本，各语言的选项名不同
+func WithDirOpt(d Dep, opt string) Dep {
+	d.DirOpt = opt
+	return d
+}
+
+// Preflight 检查 Toolchain 和 deps，逐项通过 logf 报告；cflags 是构建时额外的编译参数（如 -I）。
+// 缺少必需依赖时返回附带安装命令的错误，缺少可选依赖时只警告。
+func Preflight(logf func(format string, args ...any), deps []Dep, cflags []string) error {
+	if Skip {
+		logf("Skipping build dependency checks (--skip-preflight)")
+		return nil
+	}
+	var missing, optional []Dep
+	all := append(append([]Dep{}, Toolchain...), deps...)
+	cc := compiler()
+	for _, d := range all {
+		ok := false
+		switch {
+		case d.Command == "cc":
+			ok = cc != ""
+		case d.Command != "":
+			_, err := exec.LookPath(d.Command)
+			ok = err == nil
+		case cc == "":
+			// 没有编译器无法检查头文件，只报告编译器缺失
+			continue
+		default:
+			ok = hasHeader(cc, d.Headers, cflags)
+		}
+		switch {
+		case ok:
+			continue
+		case d.Optional:
+			optional = append(optional, d)
+		default:
+			missing = append(missing, d)
+		}
+	}
+	if len(optional) > 0 {
+		logf("Warning: optional dependencies not found: %s", names(optional))
+		logf("  The matching modules will be unavailable. To include them: %s", Hint(optional))
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("missing build dependencies: %s\n  install them with: %s\n  or rerun with --skip-preflight to build anyway", names(missing), Hint(missing))
+	}
+	logf("Build dependencies OK")
+	return nil
+}
+
+// Hint 返回安装 deps 的命令，无法识别发行版时列出依赖名称
+func Hint(deps []Dep) string {
+	return hint(Distro(), deps)
+}
+
+func hint(distro string, deps []Dep) string {
+	var pkgs, dirOpts []string
+	seen := map[string]bool{}
+	xcode := false
+	for _, d := range deps {
+		if distro == MacOS && d.Command == "cc" {
+			xcode = true
+			continue
+		}
+		pkg, ok := d.Packages[distro]
+		if !ok || pkg == "" || seen[pkg] {
+			continue
+		}
+		seen[pkg] = true
+		pkgs = append(pkgs, pkg)
+		// brew 的 openssl@3 是 keg-only，不在默认搜索路径中，需要显式指定目录
+		if distro == MacOS && d.DirOpt != "" {
+			dirOpts = append(dirOpts, fmt.Sprintf("--configure-opt=%s=$(brew --prefix %s)", d.DirOpt, pkg))
+		}
+	}
+	list := strings.Join(pkgs, " ")
+	switch distro {
+	case Debian:
+		return "sudo apt-get install -y " + list
+	case RHEL:
+		return "sudo dnf install -y " + list
+	case Alpine:
+		return "sudo apk add " + list
+	case Arch:
+		return "sudo pacman -S --needed " + list
+	case MacOS:
+		var cmds []string
+		if xcode {
+			cmds = append(cmds, "xcode-select --install")
+		}
+		if len(pkgs) > 0 {
+			cmds = append(cmds, "brew install "+list)
+		}
+		h := strings.Join(cmds, " && ")
+		if len(dirOpts) > 0 {
+			h += ", then rerun with " + strings.Join(dirOpts, " ")
+		}
+		return h
+	}
+	return "install the development packages for " + names(deps)
+}
+
+// Distro 根据 /etc/os-release 的 ID 和 ID_LIKE 识别发行版家族，无法识别时返回空
+func Distro() string {
+	if runtime.GOOS == "darwin" {
+		return MacOS
+	}
+	f, err := os.Open("/etc/os-release")
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	var ids []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), "=")
+		if ok && (key == "ID" || key == "ID_LIKE") {
+			ids = append(ids, strings.Fields(strings.Trim(value, `"'`))...)
+		}
+	}
+	for _, id := range ids {
+		switch id {
+		case "debian", "ubuntu":
+			return Debian
+		case "rhel", "fedora", "centos", "rocky", "almalinux", "amzn":
+			return RHEL
+		case "alpine":
+			return Alpine
+		case "arch", "manjaro":
+			return Arch
+		}
+	}
+	return ""
+}
+
+// compiler 返回可用的 C 编译器，优先 $CC
+func compiler() string {
+	candidates := []string{"cc", "gcc", "clang"}
+	if cc := strings.Fields(os.Getenv("CC")); len(cc) > 0 {
+		candidates = append([]string{cc[0]}, candidates...)
+	}
+	for _, c := range candidates {
+		if path, err := exec.LookPath(c); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// hasHeader 用编译器预处理 #include 判断头文件是否可用，会考虑 CPPFLAGS/CFLAGS 中的 -I
+func hasHeader(cc string, headers, cflags []string) bool {
+	flags := append(strings.Fields(os.Getenv("CPPFLAGS")), strings.Fields(os.Getenv("CFLAGS"))...)
+	flags = append(flags, cflags...)
+	for _, h := range headers {
+		args := append(append([]string{}, flags...), "-E", "-x", "c", "-o", os.DevNull, "-")
+		cmd := exec.Command(cc, args...)
+		cmd.Stdin = strings.NewReader("#include <" + h + ">\n")
+		if cmd.Run() == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func names(deps []Dep) string {
+	var out []string
+	for _, d := range deps {
+		out = append(out, d.Name)
+	}
+	return strings.Join(out, ", ")
+}
+
+// Module 是安装后应能加载的扩展模块及其依赖的库
+type Module struct {
+	Name string
+	Dep  Dep
+}
+
+// CheckModules 运行 name args... <模块名...>，命令应逐行输出加载失败的模块名；
+// 有模块不可用时返回说明缺少哪些库的错误
+func CheckModules(modules []Module, name string, args ...string) error {
+	for _, m := range modules {
+		args = append(args, m.Name)
+	}
+	out, err := exec.Command(name, args...).Output()
+	if err != nil {
+		return fmt.Errorf("failed to check modules with %s: %w", name, err)
+	}
+	return MissingModules(modules, strings.Fields(string(out)))
+}
+
+// MissingModules 根据加载失败的模块名生成报告，全部可用时返回 nil
+func MissingModules(modules []Module, failed []string) error {
+	failedSet := map[string]bool{}
+	for _, name := range failed {
+		failedSet[name] = true
+	}
+	var items []string
+	var deps []Dep
+	for _, m := range modules {
+		if failedSet[m.Name] {
+			items = append(items, fmt.Sprintf("%s (%s)", m.Name, m.Dep.Name))
+			deps = append(deps, m.Dep)
+		}
+	}
+	if len(items) == 0 {
+		return nil
+	}
+	return fmt.Errorf("modules unavailable: %s\n  install the libraries with: %s\n  then uninstall and reinstall this version", strings.Join(items, ", "), Hint(deps))
+}
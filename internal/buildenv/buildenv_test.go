@@ -0,0 +1,54 @@
+// Copyright (c) 2025 kk
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package buildenv
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestHint(t *testing.T) {
+	ssl := WithDirOpt(OpenSSL, "--with-openssl")
+	tests := []struct {
+		distro string
+		deps   []Dep
+		want   string
+	}{
+		{Debian, []Dep{Toolchain[0], OpenSSL, Zlib}, "sudo apt-get install -y build-essential libssl-dev zlib1g-dev"},
+		{RHEL, []Dep{OpenSSL, Libffi}, "sudo dnf install -y openssl-devel libffi-devel"},
+		{Alpine, []Dep{Toolchain[1], XZ}, "sudo apk add make xz-dev"},
+		{Arch, []Dep{OpenSSL, OpenSSL}, "sudo pacman -S --needed openssl"},
+		// macOS 的 zlib 系统自带，缺少编译器时仍要列出 brew 包
+		{MacOS, []Dep{Toolchain[0], Zlib, LibYAML}, "xcode-select --install && brew install libyaml"},
+		{MacOS, []Dep{Toolchain[0]}, "xcode-select --install"},
+		{MacOS, []Dep{ssl, Libffi}, "brew install openssl@3 libffi, then rerun with --configure-opt=--with-openssl=$(brew --prefix openssl@3)"},
+		{"", []Dep{OpenSSL, Zlib}, "install the development packages for OpenSSL, zlib"},
+	}
+	for _, tt := range tests {
+		if got := hint(tt.distro, tt.deps); got != tt.want {
+			t.Errorf("hint(%q, %s) = %q, want %q", tt.distro, names(tt.deps), got, tt.want)
+		}
+	}
+}
+
+func TestIncludeFlags(t *testing.T) {
+	tests := []struct {
+		cflags string
+		opts   []string
+		want   []string
+	}{
+		{"", nil, nil},
+		{"-I/opt/include -O2", nil, []string{"-I/opt/include", "-O2"}},
+		{"", []string{"--with-openssl=/opt/ssl", "--with-openssl-dir=/usr/local/ssl"}, []string{"-I/opt/ssl/include", "-I/usr/local/ssl/include"}},
+		// 相对路径、非 --with- 选项和不带值的选项不处理
+		{"", []string{"--with-openssl=ssl", "--prefix=/opt", "--enable-shared", "--with-lto"}, nil},
+	}
+	for _, tt := range tests {
+		if got := IncludeFlags(tt.cflags, tt.opts); !slices.Equal(got, tt.want) {
+			t.Errorf("IncludeFlags(%q, %q) = %q, want %q", tt.cflags, tt.opts, got, tt.want)
+		}
+	}
+}
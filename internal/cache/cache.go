@@ -0,0 +1,210 @@
+// Copyright (c) 2025 kk
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+// Package cache 管理 ~/.kver/cache/downloads 下的安装包缓存。
+// 缓存按 URL 和校验和寻址，每个条目是一个目录，包含安装包本身和 meta.json。
+// 只有通过校验的文件才会进入缓存，因此离线时凭缓存即可重新安装。
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"kver/internal/paths"
+)
+
+const metaFile = "meta.json"
+
+// Entry 是一个缓存条目
+type Entry struct {
+	Key      string    `json:"-"`
+	URL      string    `json:"url"`
+	Checksum string    `json:"checksum"`
+	Size     int64     `json:"size"`
+	Created  time.Time `json:"created"`
+	LastUsed time.Time `json:"last_used"`
+}
+
+// Key 由 URL 和校验和计算缓存键
+func Key(url, checksum string) string {
+	sum := sha256.Sum256([]byte(url + "\n" + checksum))
+	return hex.EncodeToString(sum[:])
+}
+
+// Dir 返回条目目录
+func (e *Entry) Dir() string {
+	return filepath.Join(paths.Downloads(), e.Key)
+}
+
+// File 返回缓存的安装包路径
+func (e *Entry) File() string {
+	return filepath.Join(e.Dir(), path.Base(e.URL))
+}
+
+// Lookup 查找 URL 与校验和均匹配的缓存，并更新最近使用时间
+func Lookup(url, checksum string) (*Entry, bool) {
+	e, err := load(Key(url, checksum))
+	if err != nil || e.URL != url || e.Checksum != checksum {
+		return nil, false
+	}
+	if _, err := os.Stat(e.File()); err != nil {
+		return nil, false
+	}
+	e.touch()
+	return e, true
+}
+
+// LookupURL 查找某 URL 最近使用的缓存，用于无法获取上游校验和的离线安装
+func LookupURL(url string) (*Entry, bool) {
+	entries, err := List()
+	if err != nil {
+		return nil, false
+	}
+	var found *Entry
+	for _, e := range entries {
+		if e.URL != url {
+			continue
+		}
+		if _, err := os.Stat(e.File()); err != nil {
+			continue
+		}
+		if found == nil || e.LastUsed.After(found.LastUsed) {
+			found = e
+		}
+	}
+	if found != nil {
+		found.touch()
+	}
+	return found, found != nil
+}
+
+// Store 将已校验的文件放入缓存，src 会被移动（跨文件系统时复制）
+func Store(url, checksum, src string) (*Entry, error) {
+	fi, err := os.Stat(src)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	e := &Entry{Key: Key(url, checksum), URL: url, Checksum: checksum, Size: fi.Size(), Created: now, LastUsed: now}
+	os.RemoveAll(e.Dir())
+	if err := os.MkdirAll(e.Dir(), 0755); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(src, e.File()); err != nil {
+		if err := copyFile(src, e.File()); err != nil {
+			os.RemoveAll(e.Dir())
+			return nil, err
+		}
+	}
+	if err := e.save(); err != nil {
+		os.RemoveAll(e.Dir())
+		return nil, err
+	}
+	return e, nil
+}
+
+// List 返回所有缓存条目，按最近使用时间倒序
+func List() ([]*Entry, error) {
+	dirs, err := os.ReadDir(paths.Downloads())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var entries []*Entry
+	for _, d := range dirs {
+		if !d.IsDir() {
+			continue
+		}
+		if e, err := load(d.Name()); err == nil {
+			entries = append(entries, e)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].LastUsed.After(entries[j].LastUsed)
+	})
+	return entries, nil
+}
+
+// Remove 删除一个缓存条目
+func Remove(e *Entry) error {
+	return os.RemoveAll(e.Dir())
+}
+
+// Clean 清空下载缓存
+func Clean() error {
+	return os.RemoveAll(paths.Downloads())
+}
+
+// Prune 删除超过 age 未使用的条目，返回被删除的条目
+func Prune(age time.Duration) ([]*Entry, error) {
+	entries, err := List()
+	if err != nil {
+		return nil, err
+	}
+	cutoff := time.Now().Add(-age)
+	var removed []*Entry
+	for _, e := range entries {
+		if e.LastUsed.Before(cutoff) {
+			if err := Remove(e); err != nil {
+				return removed, err
+			}
+			removed = append(removed, e)
+		}
+	}
+	return removed, nil
+}
+
+func load(key string) (*Entry, error) {
+	data, err := os.ReadFile(filepath.Join(paths.Downloads(), key, metaFile))
+	if err != nil {
+		return nil, err
+	}
+	e := &Entry{}
+	if err := json.Unmarshal(data, e); err != nil {
+		return nil, fmt.Errorf("invalid cache entry %s: %w", key, err)
+	}
+	e.Key = key
+	return e, nil
+}
+
+func (e *Entry) save() error {
+	data, err := json.MarshalIndent(e, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(e.Dir(), metaFile), data, 0644)
+}
+
+func (e *Entry) touch() {
+	e.LastUsed = time.Now()
+	e.save()
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
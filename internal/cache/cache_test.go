@@ -0,0 +1,91 @@
+// Copyright (c) 2025 kk
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// store 把内容为 data 的文件放入缓存
+func store(t *testing.T, url, checksum, data string) *Entry {
+	t.Helper()
+	src := filepath.Join(t.TempDir(), "download")
+	os.WriteFile(src, []byte(data), 0644)
+	e, err := Store(url, checksum, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(src); err == nil {
+		t.Errorf("Store should move %s into the cache", src)
+	}
+	return e
+}
+
+func TestLookup(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	const url = "https://example.com/dist/node-v20.12.2-linux-x64.tar.gz"
+	e := store(t, url, "sha256:aaaa", "archive")
+	if filepath.Base(e.File()) != "node-v20.12.2-linux-x64.tar.gz" {
+		t.Errorf("File() = %s, want the URL's base name", e.File())
+	}
+	if data, _ := os.ReadFile(e.File()); string(data) != "archive" {
+		t.Errorf("cached file = %q", data)
+	}
+
+	if got, ok := Lookup(url, "sha256:aaaa"); !ok || got.Key != e.Key {
+		t.Errorf("Lookup with matching checksum = %v, %v", got, ok)
+	}
+	// 上游重新发布同名文件后校验和不同，不能复用旧缓存
+	if _, ok := Lookup(url, "sha256:bbbb"); ok {
+		t.Errorf("Lookup with another checksum should miss")
+	}
+	if _, ok := Lookup("https://mirror.example.com/node-v20.12.2-linux-x64.tar.gz", "sha256:aaaa"); ok {
+		t.Errorf("Lookup with another URL should miss")
+	}
+
+	os.Remove(e.File())
+	if _, ok := Lookup(url, "sha256:aaaa"); ok {
+		t.Errorf("Lookup should miss when the cached file is gone")
+	}
+}
+
+func TestLookupURL(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	const url = "https://example.com/go1.22.1.linux-amd64.tar.gz"
+	old := store(t, url, "sha256:aaaa", "old")
+	old.LastUsed = time.Now().Add(-time.Hour)
+	old.save()
+	store(t, url, "sha256:bbbb", "new")
+	store(t, "https://example.com/other.tar.gz", "sha256:cccc", "other")
+
+	e, ok := LookupURL(url)
+	if !ok || e.Checksum != "sha256:bbbb" {
+		t.Errorf("LookupURL = %v, %v, want the most recently used entry", e, ok)
+	}
+	if _, ok := LookupURL("https://example.com/missing.tar.gz"); ok {
+		t.Errorf("LookupURL of unknown URL should miss")
+	}
+}
+
+func TestPrune(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	stale := store(t, "https://example.com/a.tar.gz", "sha256:aaaa", "a")
+	stale.LastUsed = time.Now().Add(-48 * time.Hour)
+	stale.save()
+	store(t, "https://example.com/b.tar.gz", "sha256:bbbb", "b")
+
+	removed, err := Prune(24 * time.Hour)
+	if err != nil || len(removed) != 1 || removed[0].Key != stale.Key {
+		t.Fatalf("Prune = %v, %v, want only the stale entry", removed, err)
+	}
+	entries, _ := List()
+	if len(entries) != 1 || entries[0].URL != "https://example.com/b.tar.gz" {
+		t.Errorf("List after Prune = %v", entries)
+	}
+}
@@ -0,0 +1,183 @@
+// Copyright (c) 2025 kk
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+// Package config 读取用户配置 ~/.kver/config.toml。
+// 支持 TOML 的常用子集：[section]、key = value、字符串、布尔、整数和字符串数组。
+//
+//	[sources]
+//	nvmrc = false
+//
+//	[python]
+//	configure_opts = ["--enable-optimizations"]
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"kver/internal/paths"
+)
+
+// Config 是解析后的配置，键为 section -> key -> 原始值
+type Config struct {
+	sections map[string]map[string]string
+}
+
+var (
+	loadOnce sync.Once
+	loaded   *Config
+)
+
+// Path 返回配置文件路径
+func Path() string {
+	return filepath.Join(paths.Home(), "config.toml")
+}
+
+// Get 返回全局配置，首次调用时从磁盘加载；解析失败时打印警告并使用空配置
+func Get() *Config {
+	loadOnce.Do(func() {
+		data, err := os.ReadFile(Path())
+		if err != nil {
+			loaded = &Config{sections: map[string]map[string]string{}}
+			return
+		}
+		c, err := Parse(data)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[kver] Ignoring %s: %v\n", Path(), err)
+			c = &Config{sections: map[string]map[string]string{}}
+		}
+		loaded = c
+	})
+	return loaded
+}
+
+// Parse 解析配置内容
+func Parse(data []byte) (*Config, error) {
+	c := &Config{sections: map[string]map[string]string{}}
+	section := ""
+	c.sections[section] = map[string]string{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	n := 0
+	for scanner.Scan() {
+		n++
+		line := strings.TrimSpace(stripComment(scanner.Text()))
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			if c.sections[section] == nil {
+				c.sections[section] = map[string]string{}
+			}
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected key = value", n)
+		}
+		key = strings.Trim(strings.TrimSpace(key), `"`)
+		c.sections[section][key] = strings.TrimSpace(value)
+	}
+	return c, scanner.Err()
+}
+
+// stripComment 去掉不在字符串内的 # 注释
+func stripComment(line string) string {
+	inStr := false
+	for i, r := range line {
+		switch r {
+		case '"':
+			inStr = !inStr
+		case '#':
+			if !inStr {
+				return line[:i]
+			}
+		}
+	}
+	return line
+}
+
+func (c *Config) raw(section, key string) (string, bool) {
+	if c == nil || c.sections[section] == nil {
+		return "", false
+	}
+	v, ok := c.sections[section][key]
+	return v, ok
+}
+
+// String 返回字符串配置，未设置时返回 def
+func (c *Config) String(section, key, def string) string {
+	v, ok := c.raw(section, key)
+	if !ok {
+		return def
+	}
+	return unquote(v)
+}
+
+// Bool 返回布尔配置，未设置或无法解析时返回 def
+func (c *Config) Bool(section, key string, def bool) bool {
+	v, ok := c.raw(section, key)
+	if !ok {
+		return def
+	}
+	b, err := strconv.ParseBool(unquote(v))
+	if err != nil {
+		return def
+	}
+	return b
+}
+
+// Int 返回整数配置，未设置或无法解析时返回 def
+func (c *Config) Int(section, key string, def int) int {
+	v, ok := c.raw(section, key)
+	if !ok {
+		return def
+	}
+	n, err := strconv.Atoi(unquote(v))
+	if err != nil {
+		return def
+	}
+	return n
+}
+
+// Strings 返回字符串数组配置；单个字符串按空白拆分
+func (c *Config) Strings(section, key string) []string {
+	v, ok := c.raw(section, key)
+	if !ok {
+		return nil
+	}
+	if !strings.HasPrefix(v, "[") {
+		return strings.Fields(unquote(v))
+	}
+	var out []string
+	inStr := false
+	start := 1
+	body := strings.TrimSuffix(v, "]")
+	for i := 1; i <= len(body); i++ {
+		if i < len(body) && body[i] == '"' {
+			inStr = !inStr
+		}
+		if i == len(body) || (body[i] == ',' && !inStr) {
+			if item := strings.TrimSpace(body[start:i]); item != "" {
+				out = append(out, unquote(item))
+			}
+			start = i + 1
+		}
+	}
+	return out
+}
+
+func unquote(v string) string {
+	if s, err := strconv.Unquote(v); err == nil {
+		return s
+	}
+	return strings.Trim(v, `'`)
+}
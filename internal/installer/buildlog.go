@@ -0,0 +1,91 @@
+// Copyright (c) 2025 kk
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package installer
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"kver/internal/paths"
+)
+
+// Verbose 为 true 时源码构建的输出同时实时显示在终端（--verbose）
+var Verbose bool
+
+// failureTail 是构建失败时显示的日志行数
+const failureTail = 25
+
+// errorLine 匹配构建日志中可能指出失败原因的行
+var errorLine = regexp.MustCompile(`(?i)\b(error|fatal|failed|cannot|undefined reference|not found|no such file|missing)\b`)
+
+// openLog 创建 ~/.kver/logs/<lang>-<version>-<timestamp>.log，记录各构建阶段的输出
+func (c *Context) openLog() error {
+	if err := os.MkdirAll(paths.Logs(), 0755); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s-%s-%s.log", c.Spec.Lang, c.Spec.Version, time.Now().Format("20060102-150405"))
+	f, err := os.Create(filepath.Join(paths.Logs(), name))
+	if err != nil {
+		return err
+	}
+	c.log = f
+	c.LogPath = f.Name()
+	return nil
+}
+
+// logf 向构建日志写入一行说明
+func (c *Context) logf(format string, args ...any) {
+	if c.log != nil {
+		fmt.Fprintf(c.log, format+"\n", args...)
+	}
+}
+
+// output 返回构建命令的输出目标：写入日志，--verbose 时同时输出到终端
+func (c *Context) output(term *os.File) io.Writer {
+	if c.log == nil {
+		return term
+	}
+	if Verbose {
+		return io.MultiWriter(term, c.log)
+	}
+	return c.log
+}
+
+// failureSummary 返回日志末尾与错误相关的行；没有匹配时返回最后几行
+func failureSummary(path string, n int) []string {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	var all, matched []string
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+		if line == "" {
+			continue
+		}
+		all = append(all, line)
+		if errorLine.MatchString(line) && !strings.HasPrefix(line, "checking ") {
+			matched = append(matched, line)
+		}
+	}
+	lines := matched
+	if len(lines) == 0 {
+		lines = all
+	}
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return lines
+}
@@ -0,0 +1,79 @@
+// Copyright (c) 2025 kk
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package installer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func writeLog(t *testing.T, lines ...string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "build.log")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestFailureSummary(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines []string
+		n     int
+		want  []string
+	}{
+		{
+			"error lines only",
+			[]string{
+				"checking for openssl/ssl.h... not found",
+				"gcc -c foo.c",
+				"foo.c:12: error: unknown type name 'SSL'",
+				"   ",
+				"make: *** [foo.o] Error 1",
+			},
+			25,
+			[]string{"foo.c:12: error: unknown type name 'SSL'", "make: *** [foo.o] Error 1"},
+		},
+		{
+			"last matching lines",
+			[]string{"ld: undefined reference to `ffi_call'", "fatal: a", "fatal: b"},
+			2,
+			[]string{"fatal: a", "fatal: b"},
+		},
+		{
+			"no match falls back to tail",
+			[]string{"one", "", "two", "three"},
+			2,
+			[]string{"two", "three"},
+		},
+	}
+	for _, tt := range tests {
+		got := failureSummary(writeLog(t, tt.lines...), tt.n)
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s: failureSummary = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+	if got := failureSummary(filepath.Join(t.TempDir(), "missing.log"), 5); got != nil {
+		t.Errorf("failureSummary of missing log = %q", got)
+	}
+}
+
+func TestFailureSummaryLongLog(t *testing.T) {
+	var lines []string
+	for i := range 1000 {
+		lines = append(lines, fmt.Sprintf("compiling %d.c", i))
+	}
+	lines = append(lines, strings.Repeat("x", 200*1024), "collect2: error: ld returned 1 exit status")
+	got := failureSummary(writeLog(t, lines...), failureTail)
+	if !slices.Equal(got, []string{"collect2: error: ld returned 1 exit status"}) {
+		t.Errorf("failureSummary = %q", got)
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright (c) 2025 kk
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package installer
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"kver/internal/progress"
+)
+
+// SkipVerify 为 true 时跳过校验和检查，由 --skip-verify 设置
+var SkipVerify bool
+
+// NewChecksum 组合算法与十六进制摘要，如 NewChecksum("sha256", "ab12...")
+func NewChecksum(algo, digest string) string {
+	return algo + ":" + strings.ToLower(strings.TrimSpace(digest))
+}
+
+// VerifyFile 校验文件摘要，checksum 形如 sha256:<hex>，支持 sha256、sha512、md5
+func VerifyFile(path, checksum string) error {
+	algo, want, ok := strings.Cut(checksum, ":")
+	if !ok || want == "" {
+		return fmt.Errorf("invalid checksum: %q", checksum)
+	}
+	var h hash.Hash
+	switch algo {
+	case "sha256":
+		h = sha256.New()
+	case "sha512":
+		h = sha512.New()
+	case "md5":
+		h = md5.New()
+	default:
+		return fmt.Errorf("unsupported checksum algorithm: %s", algo)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if _, err := io.Copy(h, f); err != nil {
+		return err
+	}
+	got := hex.EncodeToString(h.Sum(nil))
+	if got != want {
+		return fmt.Errorf("checksum mismatch for %s: expected %s %s, got %s", filepath.Base(path), algo, want, got)
+	}
+	return nil
+}
+
+// warnSkipVerify 醒目地提示校验已被跳过
+func warnSkipVerify(url string) {
+	fmt.Fprintln(os.Stderr, progress.Paint(os.Stderr, progress.Red, "[kver] WARNING: checksum verification is DISABLED (--skip-verify)."))
+	fmt.Fprintln(os.Stderr, progress.Paint(os.Stderr, progress.Red, fmt.Sprintf("[kver] WARNING: %s will be installed without any integrity check.", url)))
+}
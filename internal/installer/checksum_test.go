@@ -0,0 +1,49 @@
+// Copyright (c) 2025 kk
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package installer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNewChecksum(t *testing.T) {
+	if got := NewChecksum("sha256", " ABCDEF\n"); got != "sha256:abcdef" {
+		t.Errorf("NewChecksum = %q", got)
+	}
+}
+
+func TestVerifyFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pkg.tar.gz")
+	os.WriteFile(path, []byte("hello\n"), 0644)
+	tests := []struct {
+		checksum string
+		err      string // 为空表示校验通过
+	}{
+		{"sha256:5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03", ""},
+		{NewChecksum("sha256", "5891B5B522D5DF086D0FF0B110FBD9D21BB4FC7163AF34D08286A2E846F6BE03"), ""},
+		{"sha512:e7c22b994c59d9cf2b48e549b1e24666636045930d3da7c1acb299d1c3b7f931f94aae41edda2c2b207a36e10f8bcb8d45223e54878f5b316e7ce3b6bc019629", ""},
+		{"md5:b1946ac92492d2347c6235b4d2611184", ""},
+		{"sha256:0000000000000000000000000000000000000000000000000000000000000000", "checksum mismatch"},
+		{"sha1:f572d396fae9206628714fb2ce00f72e94f2258f", "unsupported checksum algorithm"},
+		{"5891b5b522d5df086d0ff0b110fbd9d2", "invalid checksum"},
+		{"sha256:", "invalid checksum"},
+	}
+	for _, tt := range tests {
+		err := VerifyFile(path, tt.checksum)
+		switch {
+		case tt.err == "" && err != nil:
+			t.Errorf("VerifyFile(%s): %v", tt.checksum, err)
+		case tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)):
+			t.Errorf("VerifyFile(%s) = %v, want %q", tt.checksum, err, tt.err)
+		}
+	}
+	if err := VerifyFile(filepath.Join(t.TempDir(), "missing"), "md5:b1946ac92492d2347c6235b4d2611184"); err == nil {
+		t.Errorf("VerifyFile of missing file should fail")
+	}
+}
@@ -0,0 +1,441 @@
+// Copyright (c) 2025 kk
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+// Package installer 负责所有插件共用的安装流程：
+// 下载、解压、构建/移动到安装目录以及失败回滚。
+// 插件只需用 Spec 描述安装包和额外步骤。
+//
+// 每次安装都在 ~/.kver/tmp 下的私有暂存目录中进行，写入完成标记后
+// 才整体改名为正式安装目录；失败或被中断时只需删除暂存目录。
+package installer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"kver/internal/archive"
+	"kver/internal/cache"
+	"kver/internal/paths"
+	"kver/internal/progress"
+)
+
+// Artifact 描述需要下载的安装包
+type Artifact struct {
+	URL     string
+	Archive string // 归档格式（archive.TarGz 等），为空时按 URL 判断
+	Strip   int    // 解压时去掉的前导路径层数，为 0 时自动进入唯一的顶层目录
+	// Checksum 返回上游发布的摘要，形如 sha256:<hex>，下载后解压前校验
+	Checksum func() (string, error)
+}
+
+// Step 是安装过程中的一个步骤
+type Step struct {
+	Title string
+	Run   func(c *Context) error
+}
+
+// Spec 描述一次安装
+type Spec struct {
+	Lang        string // 语言标识，如 go、nodejs
+	Name        string // 显示名称，如 Go、Node.js
+	Version     string
+	Backend     string // 安装后端，插件支持多种后端时记录到安装清单
+	LTS         string // LTS 代号（如 Node.js 的 Iron），记录到安装清单，离线时据此解析 lts 别名
+	Artifact    Artifact
+	Build       []Step // 源码构建步骤，在 SrcDir 中执行，以 --prefix=InstallDir 构建并 make install DESTDIR=DestDir
+	PostInstall []Step // 暂存目录 StageDir 就绪后、改名为正式目录前执行
+	// Preflight 在下载前检查构建依赖，返回错误时中止安装
+	Preflight func(c *Context) error
+	// Verify 在安装到正式目录后检查结果（如扩展模块能否加载），返回的错误只作为警告
+	Verify func(c *Context) error
+}
+
+// Context 是传递给各步骤的安装上下文
+type Context struct {
+	Spec       *Spec
+	WorkDir    string       // 临时工作目录，安装结束后删除
+	Archive    string       // 下载得到的安装包路径
+	SrcDir     string       // 解压后的顶层目录
+	InstallDir string       // 最终安装目录，构建时用作 prefix，安装成功前不存在
+	DestDir    string       // 源码构建的 DESTDIR，make install 后文件位于 DestDir+InstallDir
+	StageDir   string       // 暂存的安装目录，成功后改名为 InstallDir
+	LogPath    string       // 源码构建日志路径，没有构建步骤时为空
+	Options    BuildOptions // 源码构建选项，见 OptionsFor
+	Progress   progress.Reporter
+
+	ctx      context.Context
+	checksum string
+	log      *os.File
+}
+
+// Logf 输出带语言前缀的日志
+func (c *Context) Logf(format string, args ...any) {
+	c.Progress.Logf(format, args...)
+}
+
+// Command 创建在 SrcDir 中执行的命令，源码构建时输出写入构建日志（--verbose 时同时输出到终端）。
+// 命令在独立进程组中运行，安装被中断时整组终止，make 派生的编译进程不会遗留。
+func (c *Context) Command(name string, args ...string) *exec.Cmd {
+	c.logf("$ %s %s", name, strings.Join(args, " "))
+	cmd := exec.CommandContext(c.ctx, name, args...)
+	setProcessGroup(cmd)
+	cmd.WaitDelay = 10 * time.Second
+	cmd.Dir = c.SrcDir
+	cmd.Env = c.buildEnv()
+	cmd.Stdout = c.output(os.Stdout)
+	cmd.Stderr = c.output(os.Stderr)
+	return cmd
+}
+
+// Run 按 Spec 执行完整安装流程。Ctrl-C 或 SIGTERM 会中止当前步骤，
+// 任一步失败都只会留下被清理的暂存目录，正式安装目录要么不存在要么完整。
+func Run(spec *Spec) error {
+	if spec.Name == "" {
+		spec.Name = spec.Lang
+	}
+	c := &Context{
+		Spec:       spec,
+		InstallDir: paths.InstallDir(spec.Lang, spec.Version),
+		Progress:   progress.New(spec.Lang),
+	}
+	if IsInstalled(spec.Lang, spec.Version) {
+		c.Logf("%s %s is already installed at %s", spec.Name, spec.Version, c.InstallDir)
+		return nil
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	c.ctx = ctx
+
+	cleanStale()
+	if err := os.MkdirAll(paths.Tmp(), 0755); err != nil {
+		return fmt.Errorf("failed to create staging dir: %w", err)
+	}
+	staging, err := os.MkdirTemp(paths.Tmp(), spec.Lang+"-"+spec.Version+"-")
+	if err != nil {
+		return fmt.Errorf("failed to create staging dir: %w", err)
+	}
+	defer removeAll(staging)
+	c.WorkDir = filepath.Join(staging, "work")
+	c.DestDir = filepath.Join(staging, "dest")
+	c.StageDir = filepath.Join(staging, "install")
+	if err := os.Mkdir(c.WorkDir, 0755); err != nil {
+		return err
+	}
+
+	var steps []Step
+	if spec.Preflight != nil {
+		steps = append(steps, Step{Title: "Check build dependencies", Run: spec.Preflight})
+	}
+	steps = append(steps,
+		Step{Title: fmt.Sprintf("Download %s tarball", spec.Name), Run: download},
+		Step{Title: fmt.Sprintf("Extract %s tarball", spec.Name), Run: extract},
+	)
+	buildStart, buildEnd := -1, -1
+	if len(spec.Build) > 0 {
+		buildStart = len(steps)
+		steps = append(steps, spec.Build...)
+		buildEnd = len(steps) - 1
+		steps[buildEnd].Run = thenStage(steps[buildEnd].Run)
+	} else {
+		steps = append(steps, Step{Title: "Move to install directory", Run: move})
+	}
+	steps = append(steps, spec.PostInstall...)
+
+	if len(spec.Build) > 0 {
+		c.Options = OptionsFor(spec.Lang)
+		if err := c.openLog(); err != nil {
+			return fmt.Errorf("failed to create build log: %w", err)
+		}
+		defer c.log.Close()
+		c.logf("build options: %+v", c.Options)
+		if len(c.Options.ConfigureOpts) > 0 {
+			c.Logf("Configure options: %s", strings.Join(c.Options.ConfigureOpts, " "))
+		}
+	}
+
+	release := func() {}
+	defer func() { release() }()
+
+	total := len(steps) + 1
+	for i, s := range steps {
+		if i == buildStart {
+			release = acquireBuild(c)
+		}
+		c.Progress.Step(i+1, total, s.Title)
+		building := i >= buildStart && i <= buildEnd
+		c.logf("==> %s", s.Title)
+		var err error
+		if building && !Verbose {
+			spin := c.Progress.Spin(s.Title)
+			err = s.Run(c)
+			if elapsed := spin.Stop(); err == nil {
+				c.Logf("%s finished in %s", s.Title, elapsed.Round(time.Second))
+			}
+		} else {
+			err = s.Run(c)
+		}
+		if i == buildEnd {
+			release()
+			release = func() {}
+		}
+		if ctx.Err() != nil {
+			return fmt.Errorf("install interrupted, cleaned up %s", staging)
+		}
+		if err != nil {
+			if building {
+				c.reportFailure()
+			}
+			return err
+		}
+	}
+	if err := commit(c); err != nil {
+		return err
+	}
+
+	c.Progress.Step(total, total, fmt.Sprintf("%s %s installed successfully!", spec.Name, spec.Version))
+	c.Logf("Installed at: %s", c.InstallDir)
+	if spec.Verify != nil {
+		if err := spec.Verify(c); err != nil {
+			c.Logf("Warning: %v", err)
+		}
+	}
+	if c.LogPath != "" {
+		c.Logf("Build log: %s", c.LogPath)
+	}
+	return nil
+}
+
+// reportFailure 显示构建日志中与失败相关的最后几行以及日志路径
+func (c *Context) reportFailure() {
+	if c.log == nil {
+		return
+	}
+	c.log.Sync()
+	if !Verbose {
+		if lines := failureSummary(c.LogPath, failureTail); len(lines) > 0 {
+			c.Logf("Last relevant lines of the build log:")
+			for _, l := range lines {
+				fmt.Println("    " + l)
+			}
+		}
+	}
+	c.Logf("Full build log: %s", c.LogPath)
+}
+
+// buildMu 保证同一进程内同时只有一个源码构建，各自的 make -jN 已占满 CPU
+var buildMu sync.Mutex
+
+// acquireBuild 获取构建权，需要等待时提示；返回释放函数
+func acquireBuild(c *Context) func() {
+	if !buildMu.TryLock() {
+		c.Logf("Waiting for another build to finish ...")
+		buildMu.Lock()
+	}
+	return buildMu.Unlock
+}
+
+// thenStage 在最后一个构建步骤后把 DESTDIR 中的安装结果移到 StageDir
+func thenStage(run func(c *Context) error) func(c *Context) error {
+	return func(c *Context) error {
+		if err := run(c); err != nil {
+			return err
+		}
+		if _, err := os.Stat(c.StageDir); err == nil {
+			return nil
+		}
+		built := filepath.Join(c.DestDir, c.InstallDir)
+		if err := os.Rename(built, c.StageDir); err != nil {
+			return fmt.Errorf("failed to find installed files in %s: %w", built, err)
+		}
+		return nil
+	}
+}
+
+// commit 写入完成标记并将暂存目录改名为正式安装目录，替换其中残留的未完成安装
+func commit(c *Context) error {
+	if err := writeManifest(c); err != nil {
+		return fmt.Errorf("failed to write install manifest: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(c.InstallDir), 0755); err != nil {
+		return err
+	}
+	if _, err := os.Lstat(c.InstallDir); err == nil {
+		old := c.StageDir + ".old"
+		if err := os.Rename(c.InstallDir, old); err != nil {
+			return fmt.Errorf("failed to replace %s: %w", c.InstallDir, err)
+		}
+	}
+	if err := os.Rename(c.StageDir, c.InstallDir); err != nil {
+		return fmt.Errorf("failed to move %s dir: %w", c.Spec.Lang, err)
+	}
+	return nil
+}
+
+// cleanStale 删除一天前遗留的暂存目录（例如进程被 SIGKILL 时）
+func cleanStale() {
+	entries, err := os.ReadDir(paths.Tmp())
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		if fi, err := e.Info(); err == nil && time.Since(fi.ModTime()) > 24*time.Hour {
+			removeAll(filepath.Join(paths.Tmp(), e.Name()))
+		}
+	}
+}
+
+// removeAll 删除目录，遇到只读子目录时先恢复写权限再重试
+func removeAll(dir string) {
+	if os.RemoveAll(dir) == nil {
+		return
+	}
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && d.IsDir() {
+			os.Chmod(path, 0755)
+		}
+		return nil
+	})
+	os.RemoveAll(dir)
+}
+
+// download 获取安装包：优先使用校验和匹配的缓存，否则下载、校验后放入缓存。
+// 无法获取上游校验和（如离线）时，使用该 URL 的缓存并按缓存时记录的校验和复核。
+func download(c *Context) error {
+	a := c.Spec.Artifact
+	if SkipVerify {
+		warnSkipVerify(a.URL)
+		return fetch(c)
+	}
+	var checksum string
+	sumErr := fmt.Errorf("no checksum available for %s", a.URL)
+	if a.Checksum != nil {
+		checksum, sumErr = a.Checksum()
+	}
+
+	var entry *cache.Entry
+	var ok bool
+	if sumErr == nil {
+		entry, ok = cache.Lookup(a.URL, checksum)
+	} else {
+		entry, ok = cache.LookupURL(a.URL)
+	}
+	if ok {
+		if err := VerifyFile(entry.File(), entry.Checksum); err == nil {
+			if sumErr != nil {
+				c.Logf("Checksum unavailable (%v), using cached archive verified at download time", sumErr)
+			}
+			c.Logf("Using cached %s", entry.File())
+			c.checksum = entry.Checksum
+			c.Archive = entry.File()
+			return nil
+		}
+		cache.Remove(entry)
+	}
+	if sumErr != nil {
+		return fmt.Errorf("failed to fetch checksum for %s: %w (use --skip-verify to install anyway)", a.URL, sumErr)
+	}
+
+	if err := fetch(c); err != nil {
+		return err
+	}
+	if err := VerifyFile(c.Archive, checksum); err != nil {
+		return err
+	}
+	c.Logf("Verified %s", checksum)
+	c.checksum = checksum
+	if entry, err := cache.Store(a.URL, checksum, c.Archive); err == nil {
+		c.Archive = entry.File()
+	} else {
+		c.Logf("Failed to cache download: %v", err)
+	}
+	return nil
+}
+
+// fetch 下载安装包到临时目录
+func fetch(c *Context) error {
+	url := c.Spec.Artifact.URL
+	c.Logf("Downloading %s", url)
+	req, err := http.NewRequestWithContext(c.ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to download: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return fmt.Errorf("download failed: %s", resp.Status)
+	}
+	c.Archive = filepath.Join(c.WorkDir, path.Base(url))
+	out, err := os.Create(c.Archive)
+	if err != nil {
+		return err
+	}
+	bar := c.Progress.Start("Downloaded", resp.ContentLength)
+	if _, err = io.Copy(out, bar.Reader(resp.Body)); err != nil {
+		out.Close()
+		return err
+	}
+	bar.Finish()
+	return out.Close()
+}
+
+// extract 解压安装包并定位源码目录
+func extract(c *Context) error {
+	a := c.Spec.Artifact
+	format := a.Archive
+	if format == "" {
+		f, err := archive.Detect(a.URL)
+		if err != nil {
+			return err
+		}
+		format = f
+	}
+	dest := filepath.Join(c.WorkDir, "src")
+	var size int64
+	if fi, err := os.Stat(c.Archive); err == nil {
+		size = fi.Size()
+	}
+	bar := c.Progress.Start("Extracted", size)
+	err := archive.Extract(c.Archive, dest, archive.Options{Format: format, Strip: a.Strip, Progress: bar.Add})
+	if err != nil {
+		return fmt.Errorf("failed to extract %s: %w", filepath.Base(c.Archive), err)
+	}
+	bar.Finish()
+
+	c.SrcDir = dest
+	if a.Strip == 0 {
+		entries, _ := os.ReadDir(dest)
+		if len(entries) == 1 && entries[0].IsDir() {
+			c.SrcDir = filepath.Join(dest, entries[0].Name())
+		}
+	}
+	if entries, _ := os.ReadDir(c.SrcDir); len(entries) == 0 {
+		return fmt.Errorf("failed to find extracted %s dir", c.Spec.Lang)
+	}
+	return nil
+}
+
+// move 将解压目录整体移动到暂存安装目录，用于预编译的二进制包
+func move(c *Context) error {
+	if err := os.Rename(c.SrcDir, c.StageDir); err != nil {
+		return fmt.Errorf("failed to move %s dir: %w", c.Spec.Lang, err)
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+// Copyright (c) 2025 kk
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package installer
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"kver/internal/paths"
+)
+
+// serveTarGz 启动一个提供 demo-<ver>/bin/tool 安装包的 HTTP 服务，返回下载地址和校验和
+func serveTarGz(t *testing.T, ver string) (string, string) {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	body := []byte("#!/bin/sh\necho " + ver + "\n")
+	tw.WriteHeader(&tar.Header{Name: "demo-" + ver + "/bin/tool", Mode: 0755, Size: int64(len(body)), Typeflag: tar.TypeReg})
+	tw.Write(body)
+	tw.Close()
+	gz.Close()
+	data := buf.Bytes()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(data)
+	}))
+	t.Cleanup(srv.Close)
+	sum := sha256.Sum256(data)
+	return srv.URL + "/demo-" + ver + ".tar.gz", NewChecksum("sha256", hex.EncodeToString(sum[:]))
+}
+
+func demoSpec(ver, url, checksum string) *Spec {
+	return &Spec{
+		Lang:    "demo",
+		Version: ver,
+		LTS:     "Iron",
+		Artifact: Artifact{
+			URL:      url,
+			Strip:    1,
+			Checksum: func() (string, error) { return checksum, nil },
+		},
+	}
+}
+
+func TestRun(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	url, sum := serveTarGz(t, "1.0.0")
+	if err := Run(demoSpec("1.0.0", url, sum)); err != nil {
+		t.Fatal(err)
+	}
+	tool := filepath.Join(paths.InstallDir("demo", "1.0.0"), "bin", "tool")
+	if fi, err := os.Stat(tool); err != nil || fi.Mode().Perm() != 0755 {
+		t.Errorf("stat %s = %v, %v", tool, fi, err)
+	}
+	m, err := ReadManifest("demo", "1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Lang != "demo" || m.Version != "1.0.0" || m.URL != url || m.Checksum != sum || m.LTS != "Iron" || m.InstalledAt.IsZero() {
+		t.Errorf("manifest = %+v", m)
+	}
+	if m.Build != nil {
+		t.Errorf("manifest of a binary install records build options: %+v", m.Build)
+	}
+	// 暂存目录在完成后被清理
+	if entries, _ := os.ReadDir(paths.Tmp()); len(entries) != 0 {
+		t.Errorf("staging left behind: %v", entries)
+	}
+}
+
+func TestRunFailureLeavesNothing(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	url, sum := serveTarGz(t, "1.0.0")
+	spec := demoSpec("1.0.0", url, sum)
+	spec.PostInstall = []Step{{Title: "Fail", Run: func(c *Context) error {
+		if _, err := os.Stat(filepath.Join(c.StageDir, "bin", "tool")); err != nil {
+			t.Errorf("PostInstall should run in the staging dir: %v", err)
+		}
+		return errors.New("boom")
+	}}}
+	if err := Run(spec); err == nil {
+		t.Fatal("Run should fail")
+	}
+	if _, err := os.Stat(paths.InstallDir("demo", "1.0.0")); err == nil {
+		t.Errorf("failed install created the install dir")
+	}
+	if entries, _ := os.ReadDir(paths.Tmp()); len(entries) != 0 {
+		t.Errorf("staging left behind: %v", entries)
+	}
+}
+
+func TestRunChecksumMismatch(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	url, _ := serveTarGz(t, "1.0.0")
+	err := Run(demoSpec("1.0.0", url, NewChecksum("sha256", "00")))
+	if err == nil {
+		t.Fatal("Run with a wrong checksum should fail")
+	}
+	if IsInstalled("demo", "1.0.0") {
+		t.Errorf("version installed despite checksum mismatch")
+	}
+}
+
+func TestInstalled(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	for _, ver := range []string{"1.10.0", "1.2.0"} {
+		url, sum := serveTarGz(t, ver)
+		if err := Run(demoSpec(ver, url, sum)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 没有安装标记的目录（中断的安装或旧版 kver 留下的）不算已安装
+	os.MkdirAll(filepath.Join(paths.InstallDir("demo", "1.5.0"), "bin"), 0755)
+
+	got, err := Installed("demo")
+	if want := []string{"1.2.0", "1.10.0"}; err != nil || !slices.Equal(got, want) {
+		t.Errorf("Installed = %v, %v, want %v", got, err, want)
+	}
+	if IsInstalled("demo", "1.5.0") || IsInstalled("demo", "") {
+		t.Errorf("IsInstalled should be false without a manifest")
+	}
+
+	// 重新安装会替换残留的未完成目录
+	url, sum := serveTarGz(t, "1.5.0")
+	if err := Run(demoSpec("1.5.0", url, sum)); err != nil {
+		t.Fatal(err)
+	}
+	if !IsInstalled("demo", "1.5.0") {
+		t.Errorf("1.5.0 not installed after replacing the leftover dir")
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright (c) 2025 kk
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package installer
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"kver/internal/paths"
+	"kver/internal/version"
+)
+
+// ManifestFile 是安装完成标记，位于安装目录下；没有它的目录视为未完成的安装
+const ManifestFile = ".kver-install.json"
+
+// Manifest 记录一次完成的安装
+type Manifest struct {
+	Lang        string        `json:"lang"`
+	Version     string        `json:"version"`
+	Backend     string        `json:"backend,omitempty"`
+	LTS         string        `json:"lts,omitempty"`
+	URL         string        `json:"url,omitempty"`
+	Checksum    string        `json:"checksum,omitempty"`
+	Build       *BuildOptions `json:"build,omitempty"` // 源码构建实际使用的选项
+	InstalledAt time.Time     `json:"installed_at"`
+}
+
+// ReadManifest 读取已安装版本的安装记录
+func ReadManifest(lang, ver string) (*Manifest, error) {
+	data, err := os.ReadFile(filepath.Join(paths.InstallDir(lang, ver), ManifestFile))
+	if err != nil {
+		return nil, err
+	}
+	m := &Manifest{}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// IsInstalled 判断某版本是否已完整安装
+func IsInstalled(lang, ver string) bool {
+	if ver == "" {
+		return false
+	}
+	fi, err := os.Stat(filepath.Join(paths.InstallDir(lang, ver), ManifestFile))
+	return err == nil && fi.Mode().IsRegular()
+}
+
+// Installed 返回某语言已完整安装的版本，按版本号升序
+func Installed(lang string) ([]string, error) {
+	entries, err := os.ReadDir(paths.Languages(lang))
+	if err != nil {
+		return nil, err
+	}
+	var versions []string
+	for _, e := range entries {
+		if e.IsDir() && IsInstalled(lang, e.Name()) {
+			versions = append(versions, e.Name())
+		}
+	}
+	version.Sort(versions)
+	return versions, nil
+}
+
+// writeManifest 在暂存目录中写入完成标记
+func writeManifest(c *Context) error {
+	m := Manifest{
+		Lang:        c.Spec.Lang,
+		Version:     c.Spec.Version,
+		Backend:     c.Spec.Backend,
+		LTS:         c.Spec.LTS,
+		URL:         c.Spec.Artifact.URL,
+		Checksum:    c.checksum,
+		InstalledAt: time.Now().UTC(),
+	}
+	if len(c.Spec.Build) > 0 {
+		m.Build = &c.Options
+	}
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(c.StageDir, ManifestFile), append(data, '\n'), 0644)
+}
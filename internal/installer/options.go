@@ -0,0 +1,104 @@
+// Copyright (c) 2025 kk
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package installer
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"kver/internal/config"
+)
+
+// 命令行参数（--configure-opt、--jobs），优先级最高
+var (
+	ConfigureOpts []string
+	Jobs          int
+)
+
+// BuildOptions 是源码构建的选项，会记录到安装清单中
+type BuildOptions struct {
+	ConfigureOpts []string `json:"configure_opts,omitempty"`
+	CFLAGS        string   `json:"cflags,omitempty"`
+	LDFLAGS       string   `json:"ldflags,omitempty"`
+	Jobs          int      `json:"jobs"`
+}
+
+// OptionsFor 合并某语言的构建选项，依次来自 ~/.kver/config.toml 的 [<lang>] 段、
+// KVER_<LANG>_CONFIGURE_OPTS/CFLAGS/LDFLAGS/JOBS 环境变量和命令行参数。
+// configure 选项按此顺序追加，其余选项后者覆盖前者。
+//
+//	[python]
+//	configure_opts = ["--enable-optimizations", "--with-lto"]
+//	cflags = "-O3"
+//	jobs = 4
+func OptionsFor(lang string) BuildOptions {
+	cfg := config.Get()
+	env := func(name string) string {
+		return os.Getenv("KVER_" + strings.ToUpper(lang) + "_" + name)
+	}
+	o := BuildOptions{
+		ConfigureOpts: cfg.Strings(lang, "configure_opts"),
+		CFLAGS:        cfg.String(lang, "cflags", ""),
+		LDFLAGS:       cfg.String(lang, "ldflags", ""),
+		Jobs:          cfg.Int(lang, "jobs", 0),
+	}
+	o.ConfigureOpts = append(o.ConfigureOpts, strings.Fields(env("CONFIGURE_OPTS"))...)
+	if v := env("CFLAGS"); v != "" {
+		o.CFLAGS = v
+	}
+	if v := env("LDFLAGS"); v != "" {
+		o.LDFLAGS = v
+	}
+	if v := env("JOBS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			o.Jobs = n
+		} else {
+			fmt.Fprintf(os.Stderr, "[kver] Ignoring invalid KVER_%s_JOBS=%q\n", strings.ToUpper(lang), v)
+		}
+	}
+	o.ConfigureOpts = append(o.ConfigureOpts, ConfigureOpts...)
+	if Jobs > 0 {
+		o.Jobs = Jobs
+	}
+	if o.Jobs <= 0 {
+		o.Jobs = runtime.NumCPU()
+	}
+	return o
+}
+
+// ConfigureArgs 返回 ./configure 的参数：--prefix、插件的默认参数和用户选项
+func (c *Context) ConfigureArgs(args ...string) []string {
+	out := append([]string{"--prefix=" + c.InstallDir}, args...)
+	return append(out, c.Options.ConfigureOpts...)
+}
+
+// MakeArgs 返回带并行任务数的 make 参数
+func (c *Context) MakeArgs(args ...string) []string {
+	return append([]string{"-j" + strconv.Itoa(c.Options.Jobs)}, args...)
+}
+
+// buildEnv 返回追加了用户 CFLAGS/LDFLAGS 的环境变量，未设置时返回 nil（继承当前环境）
+func (c *Context) buildEnv() []string {
+	if c.Options.CFLAGS == "" && c.Options.LDFLAGS == "" {
+		return nil
+	}
+	env := os.Environ()
+	add := func(name, value string) {
+		if value == "" {
+			return
+		}
+		if cur := os.Getenv(name); cur != "" {
+			value = cur + " " + value
+		}
+		env = append(env, name+"="+value)
+	}
+	add("CFLAGS", c.Options.CFLAGS)
+	add("LDFLAGS", c.Options.LDFLAGS)
+	return env
+}
@@ -0,0 +1,68 @@
+// Copyright (c) 2025 kk
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package installer
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"testing"
+
+	"kver/internal/paths"
+)
+
+func TestOptionsFor(t *testing.T) {
+	// config.Get 只加载一次，本包中只有这个测试读取配置
+	t.Setenv("HOME", t.TempDir())
+	os.MkdirAll(paths.Home(), 0755)
+	cfg := "[demo]\nconfigure_opts = [\"--from-config\"]\ncflags = \"-O1\"\nldflags = \"-L/cfg\"\njobs = 2\n"
+	if err := os.WriteFile(filepath.Join(paths.Home(), "config.toml"), []byte(cfg), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ConfigureOpts, Jobs = nil, 0 })
+
+	o := OptionsFor("demo")
+	if !slices.Equal(o.ConfigureOpts, []string{"--from-config"}) || o.CFLAGS != "-O1" || o.LDFLAGS != "-L/cfg" || o.Jobs != 2 {
+		t.Errorf("config only: %+v", o)
+	}
+
+	// 环境变量追加 configure 选项，覆盖其余选项
+	t.Setenv("KVER_DEMO_CONFIGURE_OPTS", "--env-a --env-b")
+	t.Setenv("KVER_DEMO_CFLAGS", "-O2")
+	t.Setenv("KVER_DEMO_JOBS", "3")
+	o = OptionsFor("demo")
+	if !slices.Equal(o.ConfigureOpts, []string{"--from-config", "--env-a", "--env-b"}) || o.CFLAGS != "-O2" || o.LDFLAGS != "-L/cfg" || o.Jobs != 3 {
+		t.Errorf("config + env: %+v", o)
+	}
+
+	// 命令行参数优先级最高
+	ConfigureOpts, Jobs = []string{"--cli"}, 8
+	o = OptionsFor("demo")
+	if !slices.Equal(o.ConfigureOpts, []string{"--from-config", "--env-a", "--env-b", "--cli"}) || o.Jobs != 8 {
+		t.Errorf("config + env + cli: %+v", o)
+	}
+
+	// 无效的 JOBS 被忽略，其他语言不受 [demo] 影响
+	ConfigureOpts, Jobs = nil, 0
+	t.Setenv("KVER_DEMO_JOBS", "many")
+	if o = OptionsFor("demo"); o.Jobs != 2 {
+		t.Errorf("invalid KVER_DEMO_JOBS: Jobs = %d, want 2", o.Jobs)
+	}
+	if o = OptionsFor("other"); len(o.ConfigureOpts) != 0 || o.CFLAGS != "" || o.Jobs != runtime.NumCPU() {
+		t.Errorf("other: %+v", o)
+	}
+}
+
+func TestConfigureArgs(t *testing.T) {
+	c := &Context{InstallDir: "/opt/demo", Options: BuildOptions{ConfigureOpts: []string{"--enable-shared"}, Jobs: 4}}
+	if got, want := c.ConfigureArgs("--with-lto"), []string{"--prefix=/opt/demo", "--with-lto", "--enable-shared"}; !slices.Equal(got, want) {
+		t.Errorf("ConfigureArgs = %q, want %q", got, want)
+	}
+	if got, want := c.MakeArgs("install"), []string{"-j4", "install"}; !slices.Equal(got, want) {
+		t.Errorf("MakeArgs = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,13 @@
+// Copyright (c) 2025 kk
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+//go:build !unix
+
+package installer
+
+import "os/exec"
+
+// 非 unix 平台没有进程组，取消时只结束构建命令本身
+func setProcessGroup(cmd *exec.Cmd) {}
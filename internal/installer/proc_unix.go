@@ -0,0 +1,20 @@
+// Copyright (c) 2025 kk
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+//go:build unix
+
+package installer
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup 让构建命令在独立的进程组中运行，取消时向整个进程组发送 SIGTERM，
+// 避免 make 派生的子进程在中止后继续运行
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error { return syscall.Kill(-cmd.Process.Pid, syscall.SIGTERM) }
+}
@@ -0,0 +1,187 @@
+// Copyright (c) 2025 kk
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+// Package kverfile 读写项目级 .kver 文件，格式为每行一个 `<lang> = <version>`，
+// 支持 # 注释。修改时保留原有注释和顺序。
+package kverfile
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"kver/internal/lock"
+)
+
+// Name 是项目版本文件名
+const Name = ".kver"
+
+// RootMarker 所在目录视为项目根，向上查找 .kver 时不再越过该目录
+const RootMarker = ".kver-root"
+
+type line struct {
+	raw     string // 非配置行（注释、空行）的原始内容
+	entry   bool
+	key     string
+	value   string
+	comment string // 行尾注释，含前导空白和 #
+}
+
+// File 是解析后的 .kver 文件
+type File struct {
+	Path  string
+	lines []line
+}
+
+// Parse 解析 .kver 文件内容
+func Parse(path string, data []byte) *File {
+	f := &File{Path: path}
+	text := strings.TrimSuffix(string(data), "\n")
+	if text == "" {
+		return f
+	}
+	for _, raw := range strings.Split(text, "\n") {
+		raw = strings.TrimSuffix(raw, "\r")
+		body, comment := raw, ""
+		if i := strings.Index(raw, "#"); i >= 0 {
+			body, comment = raw[:i], raw[i:]
+			if j := len(strings.TrimRight(body, " \t")); j < i {
+				body, comment = raw[:j], raw[j:]
+			}
+		}
+		key, value, ok := strings.Cut(body, "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			f.lines = append(f.lines, line{raw: raw})
+			continue
+		}
+		f.lines = append(f.lines, line{
+			entry:   true,
+			key:     strings.TrimSpace(key),
+			value:   strings.TrimSpace(value),
+			comment: comment,
+		})
+	}
+	return f
+}
+
+// Load 读取并解析 .kver 文件，文件不存在时返回空文件
+func Load(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &File{Path: path}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return Parse(path, data), nil
+}
+
+// Get 返回某语言的版本，重复配置时以最后一条为准
+func (f *File) Get(lang string) (string, bool) {
+	ver, ok := "", false
+	for _, l := range f.lines {
+		if l.entry && l.key == lang {
+			ver, ok = l.value, true
+		}
+	}
+	return ver, ok
+}
+
+// Set 原地更新某语言的版本并去除重复行，不存在时追加到末尾
+func (f *File) Set(lang, version string) {
+	found := false
+	lines := f.lines[:0]
+	for _, l := range f.lines {
+		if l.entry && l.key == lang {
+			if found {
+				continue
+			}
+			l.value = version
+			found = true
+		}
+		lines = append(lines, l)
+	}
+	f.lines = lines
+	if !found {
+		f.lines = append(f.lines, line{entry: true, key: lang, value: version})
+	}
+}
+
+// Bytes 将文件序列化为文本
+func (f *File) Bytes() []byte {
+	var b strings.Builder
+	for _, l := range f.lines {
+		if l.entry {
+			b.WriteString(l.key + " = " + l.value + l.comment)
+		} else {
+			b.WriteString(l.raw)
+		}
+		b.WriteString("\n")
+	}
+	return []byte(b.String())
+}
+
+// Save 原子地写回文件
+func (f *File) Save() error {
+	tmp, err := os.CreateTemp(filepath.Dir(f.Path), Name+".tmp-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(f.Bytes()); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), f.Path)
+}
+
+// Update 在文件锁内读取、修改并写回 path，防止并发的 kver local 丢失修改
+func Update(path string, fn func(f *File)) error {
+	l, err := lock.Acquire(lock.File(path), "update "+path)
+	if err != nil {
+		return err
+	}
+	defer l.Release()
+	f, err := Load(path)
+	if err != nil {
+		return err
+	}
+	fn(f)
+	return f.Save()
+}
+
+// SearchDirs 返回从 start 向上查找项目配置时依次检查的目录。
+// 到达 $HOME、包含 RootMarker 的目录或文件系统根时停止，这些目录本身也会检查。
+func SearchDirs(start string) []string {
+	home, _ := os.UserHomeDir()
+	dir, err := filepath.Abs(start)
+	if err != nil {
+		return nil
+	}
+	var dirs []string
+	for {
+		dirs = append(dirs, dir)
+		if home != "" && dir == home {
+			break
+		}
+		if _, err := os.Stat(filepath.Join(dir, RootMarker)); err == nil {
+			break
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+	return dirs
+}
@@ -0,0 +1,77 @@
+// Copyright (c) 2025 kk
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package kverfile
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestRoundTrip(t *testing.T) {
+	for _, in := range []string{
+		"",
+		"go = 1.21.0\n",
+		"# project versions\n\ngo = 1.21.0   # pinned\nnodejs = 20.12.2\n",
+		"not an entry\n  # indented comment\npython = 3.12\n",
+	} {
+		if got := string(Parse(Name, []byte(in)).Bytes()); got != in {
+			t.Errorf("round trip of %q = %q", in, got)
+		}
+	}
+}
+
+func TestSet(t *testing.T) {
+	f := Parse(Name, []byte("# project versions\n\ngo=1.21.0   # pinned\nnodejs = 18\npython = 3.11\nnodejs = 20\n"))
+	if v, ok := f.Get("nodejs"); !ok || v != "20" {
+		t.Errorf("Get(nodejs) = %q, %v, want last entry 20", v, ok)
+	}
+	if _, ok := f.Get("ruby"); ok {
+		t.Errorf("Get(ruby) should not be found")
+	}
+	f.Set("go", "1.22.1")
+	f.Set("nodejs", "20.12.2")
+	f.Set("ruby", "3.3.0")
+	want := "# project versions\n\ngo = 1.22.1   # pinned\nnodejs = 20.12.2\npython = 3.11\nruby = 3.3.0\n"
+	if got := string(f.Bytes()); got != want {
+		t.Errorf("after Set:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), Name)
+	os.WriteFile(path, []byte("# keep me\ngo = 1.21.0\n"), 0644)
+	t.Setenv("HOME", t.TempDir())
+	err := Update(path, func(f *File) { f.Set("nodejs", "20.12.2") })
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	if want := "# keep me\ngo = 1.21.0\nnodejs = 20.12.2\n"; string(data) != want {
+		t.Errorf("file = %q, want %q", data, want)
+	}
+}
+
+func TestSearchDirs(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	// TempDir 可能位于符号链接下（如 macOS 的 /var），按 Abs 后的路径比较
+	home, _ = filepath.Abs(home)
+	deep := filepath.Join(home, "a", "b")
+	os.MkdirAll(deep, 0755)
+
+	want := []string{deep, filepath.Join(home, "a"), home}
+	if got := SearchDirs(deep); !slices.Equal(got, want) {
+		t.Errorf("SearchDirs = %v, want %v", got, want)
+	}
+
+	os.WriteFile(filepath.Join(home, "a", RootMarker), nil, 0644)
+	want = []string{deep, filepath.Join(home, "a")}
+	if got := SearchDirs(deep); !slices.Equal(got, want) {
+		t.Errorf("SearchDirs with %s = %v, want %v", RootMarker, got, want)
+	}
+}
@@ -0,0 +1,122 @@
+// Copyright (c) 2025 kk
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+// Package lock 提供跨进程的排他文件锁，防止共享 ~/.kver 的多个 kver 进程
+// （如并行的 CI 任务）同时修改同一安装目录、global.json 或 .kver 文件。
+// 锁文件位于 ~/.kver/locks，进程退出时由内核自动释放。
+package lock
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"kver/internal/paths"
+)
+
+// TimeoutEnv 可覆盖等待锁的超时时间，如 10m
+const TimeoutEnv = "KVER_LOCK_TIMEOUT"
+
+// DefaultTimeout 是默认的等待时间，需覆盖一次完整的源码编译
+const DefaultTimeout = 30 * time.Minute
+
+// Lock 是一个已持有的锁
+type Lock struct {
+	f *os.File
+}
+
+// Dir 返回锁文件目录
+func Dir() string {
+	return filepath.Join(paths.Home(), "locks")
+}
+
+// Install 返回某语言版本的安装锁名，install 和 uninstall 共用
+func Install(lang, version string) string {
+	return lang + "-" + version
+}
+
+// File 返回保护某个文件的锁名
+func File(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	sum := sha256.Sum256([]byte(path))
+	return "file-" + hex.EncodeToString(sum[:8])
+}
+
+// Acquire 获取名为 name 的锁，desc 描述当前操作。
+// 锁被其他进程持有时打印提示并等待，超过超时时间返回错误。
+func Acquire(name, desc string) (*Lock, error) {
+	if err := os.MkdirAll(Dir(), 0755); err != nil {
+		return nil, err
+	}
+	path := filepath.Join(Dir(), name+".lock")
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	timeout := Timeout()
+	start := time.Now()
+	waiting := false
+	for {
+		ok, err := tryLock(f)
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("failed to lock %s: %w", path, err)
+		}
+		if ok {
+			break
+		}
+		// 短暂的竞争不提示，避免并行执行 kver global 等命令时刷屏
+		if !waiting && time.Since(start) > time.Second {
+			fmt.Fprintf(os.Stderr, "[kver] Waiting for another kver process (%s) ...\n", holder(path))
+			waiting = true
+		}
+		if time.Since(start) > timeout {
+			f.Close()
+			return nil, fmt.Errorf("timed out after %s waiting for %s (held by %s); set %s to wait longer", timeout, desc, holder(path), TimeoutEnv)
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+	// 记录持有者，供等待的进程提示
+	f.Truncate(0)
+	f.WriteAt([]byte(fmt.Sprintf("pid %d: %s\n", os.Getpid(), desc)), 0)
+	return &Lock{f: f}, nil
+}
+
+// Release 释放锁
+func (l *Lock) Release() {
+	if l == nil || l.f == nil {
+		return
+	}
+	l.f.Truncate(0)
+	unlock(l.f)
+	l.f.Close()
+	l.f = nil
+}
+
+// Timeout 返回等待锁的超时时间
+func Timeout() time.Duration {
+	if v := os.Getenv(TimeoutEnv); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d >= 0 {
+			return d
+		}
+		fmt.Fprintf(os.Stderr, "[kver] Ignoring invalid %s=%q\n", TimeoutEnv, v)
+	}
+	return DefaultTimeout
+}
+
+// holder 读取锁文件中记录的持有者
+func holder(path string) string {
+	data, _ := os.ReadFile(path)
+	if s := strings.TrimSpace(string(data)); s != "" {
+		return s
+	}
+	return "unknown process"
+}
@@ -0,0 +1,15 @@
+// Copyright (c) 2025 kk
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+//go:build !unix
+
+package lock
+
+import "os"
+
+// 非 unix 平台不支持 flock，锁退化为空操作
+func tryLock(f *os.File) (bool, error) { return true, nil }
+
+func unlock(f *os.File) {}
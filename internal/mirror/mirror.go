@@ -0,0 +1,98 @@
+// Copyright (c) 2025 kk
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+// Package mirror 决定各语言下载与版本列表使用的上游地址。
+// 优先级：环境变量 KVER_<LANG>_MIRROR > config.toml 的 [mirrors] > 官方地址。
+// 取值可以是完整 URL，也可以是内置预设名：
+//
+//	[mirrors]
+//	go = "golang.google.cn"
+//	nodejs = "npmmirror"
+package mirror
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"kver/internal/config"
+)
+
+// defaults 是各语言的官方地址
+var defaults = map[string]string{
+	"go":     "https://go.dev/dl/",
+	"nodejs": "https://nodejs.org/dist/",
+	"python": "https://www.python.org/ftp/python/",
+	"ruby":   "https://cache.ruby-lang.org/pub/ruby/",
+	// python-build-standalone 预编译包的下载地址和发布索引（GitHub API）
+	"python_standalone":     "https://github.com/astral-sh/python-build-standalone/releases/download/",
+	"python_standalone_api": "https://api.github.com/repos/astral-sh/python-build-standalone/",
+}
+
+// presets 是常用镜像
+var presets = map[string]map[string]string{
+	"go": {
+		"golang.google.cn": "https://golang.google.cn/dl/",
+	},
+	"nodejs": {
+		"npmmirror": "https://npmmirror.com/mirrors/node/",
+		"tuna":      "https://mirrors.tuna.tsinghua.edu.cn/nodejs-release/",
+		"ustc":      "https://mirrors.ustc.edu.cn/node/",
+	},
+	"python": {
+		"npmmirror":   "https://npmmirror.com/mirrors/python/",
+		"huaweicloud": "https://mirrors.huaweicloud.com/python/",
+	},
+	"ruby": {
+		"ruby-china": "https://cache.ruby-china.com/pub/ruby/",
+	},
+	"python_standalone": {
+		"npmmirror": "https://registry.npmmirror.com/-/binary/python-build-standalone/",
+	},
+}
+
+// EnvVar 返回某语言的镜像环境变量名，如 KVER_NODEJS_MIRROR
+func EnvVar(lang string) string {
+	return "KVER_" + strings.ToUpper(lang) + "_MIRROR"
+}
+
+// Base 返回某语言当前生效的上游地址，以 / 结尾
+func Base(lang string) string {
+	value := os.Getenv(EnvVar(lang))
+	if value == "" {
+		value = config.Get().String("mirrors", lang, "")
+	}
+	base := defaults[lang]
+	switch {
+	case value == "" || value == "official":
+	case strings.Contains(value, "://"):
+		base = value
+	case presets[lang][value] != "":
+		base = presets[lang][value]
+	default:
+		fmt.Fprintf(os.Stderr, "[kver] Unknown %s mirror %q (presets: %s), using %s\n",
+			lang, value, strings.Join(Presets(lang), ", "), base)
+	}
+	if !strings.HasSuffix(base, "/") {
+		base += "/"
+	}
+	return base
+}
+
+// URL 拼接上游地址与相对路径
+func URL(lang, rel string) string {
+	return Base(lang) + strings.TrimPrefix(rel, "/")
+}
+
+// Presets 返回某语言的内置镜像预设名
+func Presets(lang string) []string {
+	names := []string{"official"}
+	for name := range presets[lang] {
+		names = append(names, name)
+	}
+	sort.Strings(names[1:])
+	return names
+}
@@ -0,0 +1,51 @@
+// Copyright (c) 2025 kk
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package mirror
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestURL(t *testing.T) {
+	// config.Get 只加载一次，须在首次调用前写好配置
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	os.MkdirAll(filepath.Join(home, ".kver"), 0755)
+	config := "[mirrors]\nnodejs = \"npmmirror\"\npython = \"https://example.com/python\"\nruby = \"nope\"\n"
+	os.WriteFile(filepath.Join(home, ".kver", "config.toml"), []byte(config), 0644)
+
+	tests := []struct {
+		lang, env, want string
+	}{
+		{"go", "", "https://go.dev/dl/pkg.tar.gz"},
+		{"go", "golang.google.cn", "https://golang.google.cn/dl/pkg.tar.gz"},
+		{"nodejs", "", "https://npmmirror.com/mirrors/node/pkg.tar.gz"},
+		{"nodejs", "https://env.example.com/node", "https://env.example.com/node/pkg.tar.gz"},
+		{"nodejs", "official", "https://nodejs.org/dist/pkg.tar.gz"},
+		{"python", "", "https://example.com/python/pkg.tar.gz"},
+		{"python", "tuna", "https://www.python.org/ftp/python/pkg.tar.gz"},
+		{"ruby", "", "https://cache.ruby-lang.org/pub/ruby/pkg.tar.gz"},
+	}
+	for _, tt := range tests {
+		t.Setenv(EnvVar(tt.lang), tt.env)
+		if got := URL(tt.lang, "/pkg.tar.gz"); got != tt.want {
+			t.Errorf("URL(%s) with %s=%q = %s, want %s", tt.lang, EnvVar(tt.lang), tt.env, got, tt.want)
+		}
+	}
+}
+
+func TestPresets(t *testing.T) {
+	want := []string{"official", "npmmirror", "tuna", "ustc"}
+	if got := Presets("nodejs"); !slices.Equal(got, want) {
+		t.Errorf("Presets(nodejs) = %v, want %v", got, want)
+	}
+	if got := Presets("java"); !slices.Equal(got, []string{"official"}) {
+		t.Errorf("Presets(java) = %v", got)
+	}
+}
@@ -0,0 +1,47 @@
+// Copyright (c) 2025 kk
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package paths
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// Home 返回 kver 根目录 ~/.kver
+func Home() string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".kver")
+}
+
+// Languages 返回某语言所有版本的安装根目录
+func Languages(lang string) string {
+	return filepath.Join(Home(), "languages", lang)
+}
+
+// InstallDir 返回某语言指定版本的安装目录
+func InstallDir(lang, version string) string {
+	return filepath.Join(Languages(lang), version)
+}
+
+// Shims 返回 shim 脚本目录
+func Shims() string {
+	return filepath.Join(Home(), "shims")
+}
+
+// Downloads 返回下载缓存目录
+func Downloads() string {
+	return filepath.Join(Home(), "cache", "downloads")
+}
+
+// Tmp 返回安装暂存目录，与安装目录在同一文件系统上以便原子改名
+func Tmp() string {
+	return filepath.Join(Home(), "tmp")
+}
+
+// Logs 返回源码构建日志目录
+func Logs() string {
+	return filepath.Join(Home(), "logs")
+}
@@ -1,5 +1,7 @@
 package plugin
 
+import "strings"
+
 type Plugin interface {
 	Name() string
 	Install(version string) error
@@ -26,3 +28,67 @@ func Get(lang string) (Plugin, bool) {
 func All() map[string]Plugin {
 	return registry
 }
+
+// VersionFiler 是可选接口，声明插件能识别的其他工具的版本文件（如 .nvmrc），
+// 使 kver 无需 .kver 也能在已有项目中工作
+type VersionFiler interface {
+	// VersionFiles 按优先级返回文件名
+	VersionFiles() []string
+	// ParseVersionFile 从文件内容中解析版本号，可返回部分版本或别名
+	ParseVersionFile(name string, data []byte) (string, error)
+}
+
+// VersionFileReader 是 VersionFiler 的可选扩展，版本文件需要引用其他文件时
+// （如 Gemfile 的 ruby file: ".ruby-version"），由插件根据路径自行读取
+type VersionFileReader interface {
+	ReadVersionFile(path string) (string, error)
+}
+
+// FirstToken 返回版本文件中第一个非空、非注释行的第一个字段
+func FirstToken(data []byte) string {
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		return strings.Fields(line)[0]
+	}
+	return ""
+}
+
+// EnvProvider 是可选接口，返回运行某版本的可执行文件时需要的额外环境变量（KEY=VALUE）
+type EnvProvider interface {
+	Env(version string) []string
+}
+
+// SourceEnvProvider 是可选接口，按版本来源返回额外的环境变量，
+// 如 Go 版本来自 go.mod 时设置 GOTOOLCHAIN=local
+type SourceEnvProvider interface {
+	SourceEnv(version, requested, source string) []string
+}
+
+// Backend 是 kver install --backend 指定的安装后端，为空时由插件按配置选择默认后端
+var Backend string
+
+// BackendProvider 是可选接口，支持多种安装后端（如源码构建和预编译包）的插件实现
+type BackendProvider interface {
+	// Backends 返回支持的后端名称，第一个为默认
+	Backends() []string
+	// RemoteBackends 返回各远程版本可由哪些后端安装
+	RemoteBackends() (map[string][]string, error)
+}
+
+// Release 是远程版本的发布信息
+type Release struct {
+	Version  string
+	Date     string // 发布日期 YYYY-MM-DD，未知时为空
+	LTS      string // LTS 代号，非 LTS 版本为空
+	Security bool   // 该版本包含安全修复
+	Unstable bool   // beta、rc 等预发布版本
+	Extra    []string
+}
+
+// ReleaseLister 是可选接口，返回带元数据的远程版本，供 list-remote 过滤和显示详情
+type ReleaseLister interface {
+	Releases() ([]Release, error)
+}
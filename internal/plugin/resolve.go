@@ -0,0 +1,137 @@
+package plugin
+
+import (
+	"fmt"
+
+	"kver/internal/version"
+)
+
+// AliasResolver 是可选接口，插件可据此支持自定义版本别名（如 Node.js 的 lts/iron）。
+// 返回该别名覆盖的全部版本，ok 为 false 表示不认识此别名。
+type AliasResolver interface {
+	AliasVersions(alias string) (versions []string, ok bool, err error)
+}
+
+// InstalledAliasResolver 是可选接口，在已安装版本中解析别名，只能读取本地数据（如安装清单）。
+// 只实现 AliasResolver 的插件，别名不会匹配已安装版本
+type InstalledAliasResolver interface {
+	InstalledAliasVersions(alias string) (versions []string, ok bool, err error)
+}
+
+// RangeMatcher 是可选接口，插件可据此支持版本范围（如 package.json 的 engines.node: >=18 <21）。
+// ok 为 false 表示 query 不是范围写法，按部分版本或别名处理。
+type RangeMatcher interface {
+	MatchRange(query string) (match func(version string) bool, ok bool)
+}
+
+// ResolveRemote 将部分版本或别名解析为可安装的具体版本
+func ResolveRemote(p Plugin, query string) (string, error) {
+	remote, err := p.ListRemote()
+	if err != nil {
+		// 离线时完整版本号仍可直接使用
+		if IsConcrete(query) {
+			return query, nil
+		}
+		return "", fmt.Errorf("failed to list remote %s versions: %w", p.Name(), err)
+	}
+	var aliases func(string) ([]string, bool, error)
+	if ar, ok := p.(AliasResolver); ok {
+		aliases = ar.AliasVersions
+	}
+	return resolve(p, query, remote, aliases)
+}
+
+// ResolveInstalled 将部分版本或别名解析为已安装的具体版本，不访问网络
+func ResolveInstalled(p Plugin, query string) (string, error) {
+	installed, _ := p.List()
+	var aliases func(string) ([]string, bool, error)
+	if ar, ok := p.(InstalledAliasResolver); ok {
+		aliases = ar.InstalledAliasVersions
+	}
+	v, err := resolve(p, query, installed, aliases)
+	if err != nil {
+		return "", fmt.Errorf("%s version not installed: %s", p.Name(), query)
+	}
+	return v, nil
+}
+
+func resolve(p Plugin, query string, candidates []string, aliases func(string) ([]string, bool, error)) (string, error) {
+	for _, c := range candidates {
+		if c == query && !isPartial(query) {
+			return c, nil
+		}
+	}
+	if aliases != nil {
+		aliased, ok, err := aliases(query)
+		if err != nil {
+			return "", err
+		}
+		if ok {
+			set := map[string]bool{}
+			for _, v := range aliased {
+				set[v] = true
+			}
+			var matched []string
+			for _, c := range candidates {
+				if set[c] {
+					matched = append(matched, c)
+				}
+			}
+			if v := version.Latest(matched); v != "" {
+				return v, nil
+			}
+			return "", fmt.Errorf("no %s version matches %s", p.Name(), query)
+		}
+	}
+	if rm, ok := p.(RangeMatcher); ok {
+		if match, ok := rm.MatchRange(query); ok {
+			var matched []string
+			for _, c := range candidates {
+				if match(c) {
+					matched = append(matched, c)
+				}
+			}
+			if v := version.Latest(matched); v != "" {
+				return v, nil
+			}
+			return "", fmt.Errorf("no %s version matches %s", p.Name(), query)
+		}
+	}
+	v, err := version.Resolve(query, candidates)
+	if err != nil {
+		return "", fmt.Errorf("no %s version matches %s", p.Name(), query)
+	}
+	return v, nil
+}
+
+// Satisfies 判断具体版本 v 是否满足 query（具体版本、部分版本或范围）。
+// 别名需要联网才能展开，视为满足
+func Satisfies(p Plugin, query, v string) bool {
+	if query == v {
+		return true
+	}
+	if rm, ok := p.(RangeMatcher); ok {
+		if match, ok := rm.MatchRange(query); ok {
+			return match(v)
+		}
+	}
+	if _, err := version.Parse(query); err != nil {
+		return true
+	}
+	return version.Match(query, v)
+}
+
+// isPartial 判断查询是否为少于三段的正式版本号，如 1.22、3
+func isPartial(query string) bool {
+	v, err := version.Parse(query)
+	return err == nil && !v.IsPrerelease() && len(v.Segments) < 3
+}
+
+// IsConcrete 判断查询是否已是完整版本号（至少三段或预发布版本），而非部分版本或别名
+func IsConcrete(query string) bool {
+	v, err := version.Parse(query)
+	if err != nil {
+		return false
+	}
+	return v.IsPrerelease() || len(v.Segments) >= 3
+}
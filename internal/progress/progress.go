@@ -0,0 +1,284 @@
+// Copyright (c) 2025 kk
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+// Package progress 是安装过程的进度输出：步骤标题、日志，以及带字节数、速率和
+// 剩余时间的进度条。stdout 是终端时原地刷新进度条，否则退化为逐行日志。
+// 设置 NO_COLOR 环境变量或 --no-color 时不输出颜色。
+package progress
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// NoColor 由 --no-color 设置
+var NoColor bool
+
+// 颜色样式
+const (
+	Bold = "1"
+	Cyan = "1;36"
+	Red  = "1;31"
+)
+
+// IsTerminal 判断 f 是否为终端
+func IsTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// Color 判断输出到 f 时是否使用颜色
+func Color(f *os.File) bool {
+	return !NoColor && os.Getenv("NO_COLOR") == "" && IsTerminal(f)
+}
+
+// Paint 为输出到 f 的文本加上颜色样式，不使用颜色时原样返回
+func Paint(f *os.File, style, s string) string {
+	if !Color(f) {
+		return s
+	}
+	return "\033[" + style + "m" + s + "\033[0m"
+}
+
+// Reporter 是安装过程的进度输出接口，插件通过 installer.Context 使用
+type Reporter interface {
+	// Step 输出第 n/total 个步骤的标题
+	Step(n, total int, title string)
+	// Logf 输出一行日志
+	Logf(format string, args ...any)
+	// Start 开始一个进度条，total 未知时传 0
+	Start(label string, total int64) *Bar
+	// Spin 开始一个显示已用时间的旋转指示，用于时长未知的构建阶段，结束时调用 Stop
+	Spin(label string) *Bar
+}
+
+// New 返回带 [kver][lang] 前缀的 Reporter，按 stdout 是否为终端选择输出方式
+func New(lang string) Reporter {
+	return &reporter{prefix: "[kver][" + lang + "]", out: os.Stdout, live: IsTerminal(os.Stdout)}
+}
+
+// 并行安装时多个进度条无法在同一行刷新，同时只允许一个原地刷新的进度条
+var (
+	mu       sync.Mutex
+	liveBar  *Bar
+	drawnBar *Bar
+)
+
+type reporter struct {
+	prefix string
+	out    *os.File
+	live   bool
+}
+
+func (r *reporter) Step(n, total int, title string) {
+	line := fmt.Sprintf("%s Step %d/%d: %s", r.prefix, n, total, title)
+	if r.live {
+		line = "\n" + Paint(r.out, Cyan, line)
+	}
+	r.println(line)
+}
+
+func (r *reporter) Logf(format string, args ...any) {
+	r.println(r.prefix + " " + fmt.Sprintf(format, args...))
+}
+
+func (r *reporter) Start(label string, total int64) *Bar {
+	b := &Bar{r: r, label: label, total: total, start: time.Now()}
+	mu.Lock()
+	if r.live && liveBar == nil {
+		liveBar = b
+	}
+	mu.Unlock()
+	return b
+}
+
+func (r *reporter) Spin(label string) *Bar {
+	b := r.Start(label, 0)
+	b.spin = true
+	stop := make(chan struct{})
+	b.stop = stop
+	go func() {
+		t := time.NewTicker(100 * time.Millisecond)
+		defer t.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-t.C:
+				mu.Lock()
+				// Stop 可能在等锁期间完成，此时不再输出
+				if !b.stopped {
+					b.update(false)
+				}
+				mu.Unlock()
+			}
+		}
+	}()
+	return b
+}
+
+// println 输出一行，先清除终端上正在刷新的进度条
+func (r *reporter) println(line string) {
+	mu.Lock()
+	defer mu.Unlock()
+	clearBar()
+	fmt.Fprintln(r.out, line)
+}
+
+func clearBar() {
+	if drawnBar != nil {
+		fmt.Fprint(drawnBar.r.out, "\r\033[K")
+		drawnBar = nil
+	}
+}
+
+// Bar 是一个字节进度条
+type Bar struct {
+	r        *reporter
+	label    string
+	total    int64
+	done     int64
+	start    time.Time
+	lastDraw time.Time
+	lastStep int64
+	spin     bool
+	frame    int
+	stop     chan struct{} // 旋转指示的停止信号，只在 Stop 中关闭一次
+	stopped  bool
+}
+
+// Add 增加已完成的字节数
+func (b *Bar) Add(n int64) {
+	mu.Lock()
+	defer mu.Unlock()
+	b.done += n
+	b.update(false)
+}
+
+// Reader 返回读取时自动更新进度的 Reader
+func (b *Bar) Reader(r io.Reader) io.Reader {
+	return &reader{r: r, b: b}
+}
+
+// Stop 停止并清除进度条，不输出汇总，返回已用时间
+func (b *Bar) Stop() time.Duration {
+	mu.Lock()
+	defer mu.Unlock()
+	if !b.stopped {
+		b.stopped = true
+		if b.stop != nil {
+			close(b.stop)
+		}
+	}
+	if liveBar == b {
+		clearBar()
+		liveBar = nil
+	}
+	return time.Since(b.start)
+}
+
+// Finish 结束进度条并输出汇总
+func (b *Bar) Finish() {
+	b.Stop()
+	mu.Lock()
+	defer mu.Unlock()
+	elapsed := time.Since(b.start)
+	fmt.Fprintf(b.r.out, "%s %s %s in %s (%s/s)\n", b.r.prefix, b.label, FormatBytes(b.done),
+		elapsed.Round(100*time.Millisecond), FormatBytes(rate(b.done, elapsed)))
+}
+
+// update 刷新进度：终端中每 100ms 原地重绘，其他情况每 10%（总量未知时每 10 秒，
+// 旋转指示每分钟）输出一行，避免 CI 因长时间无输出而超时
+func (b *Bar) update(force bool) {
+	now := time.Now()
+	if liveBar == b {
+		if !force && now.Sub(b.lastDraw) < 100*time.Millisecond {
+			return
+		}
+		b.lastDraw = now
+		fmt.Fprint(b.r.out, "\r\033[K"+b.status(now))
+		drawnBar = b
+		return
+	}
+	var step int64
+	switch {
+	case b.spin:
+		step = int64(now.Sub(b.start) / time.Minute)
+	case b.total > 0:
+		step = b.done * 10 / b.total
+	default:
+		step = int64(now.Sub(b.start) / (10 * time.Second))
+	}
+	// 100% 由 Finish 的汇总行代替
+	if step > b.lastStep && (b.total <= 0 || b.done < b.total) {
+		b.lastStep = step
+		clearBar()
+		fmt.Fprintln(b.r.out, b.status(now))
+	}
+}
+
+// spinFrames 是旋转指示的帧
+var spinFrames = []string{"|", "/", "-", "\\"}
+
+func (b *Bar) status(now time.Time) string {
+	elapsed := now.Sub(b.start)
+	if b.spin {
+		b.frame++
+		if liveBar == b {
+			return fmt.Sprintf("%s %s %s  %s", b.r.prefix, spinFrames[b.frame%len(spinFrames)], b.label, elapsed.Round(time.Second))
+		}
+		return fmt.Sprintf("%s %s still running (%s)", b.r.prefix, b.label, elapsed.Round(time.Second))
+	}
+	speed := rate(b.done, elapsed)
+	var s strings.Builder
+	fmt.Fprintf(&s, "%s %s %s", b.r.prefix, b.label, FormatBytes(b.done))
+	if b.total > 0 {
+		fmt.Fprintf(&s, " / %s %3d%%", FormatBytes(b.total), b.done*100/b.total)
+	}
+	fmt.Fprintf(&s, "  %s/s", FormatBytes(speed))
+	if b.total > 0 && speed > 0 && b.done < b.total {
+		eta := time.Duration(float64(b.total-b.done) / float64(speed) * float64(time.Second))
+		fmt.Fprintf(&s, "  ETA %s", eta.Round(time.Second))
+	}
+	return s.String()
+}
+
+type reader struct {
+	r io.Reader
+	b *Bar
+}
+
+func (r *reader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if n > 0 {
+		r.b.Add(int64(n))
+	}
+	return n, err
+}
+
+func rate(n int64, d time.Duration) int64 {
+	if d <= 0 {
+		return 0
+	}
+	return int64(float64(n) / d.Seconds())
+}
+
+// FormatBytes 以 1024 进制格式化字节数，如 12.3 MiB
+func FormatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
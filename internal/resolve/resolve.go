@@ -0,0 +1,246 @@
+// Copyright (c) 2025 kk
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+// Package resolve 根据当前目录确定各语言应使用的版本。
+// 优先级：kver use 设置的 shell 版本 > 项目版本文件 > 全局默认版本。
+//
+// 从当前目录逐级向上，每级依次检查 .kver、.tool-versions 以及插件声明的版本文件，
+// 最近的目录优先。各来源可在 config.toml 的 [sources] 中开关：
+//
+//	[sources]
+//	tool-versions = true
+//	nvmrc = false
+package resolve
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"kver/internal/config"
+	"kver/internal/installer"
+	"kver/internal/kverfile"
+	"kver/internal/plugin"
+	"kver/internal/state"
+)
+
+// Result 是一次版本解析的结果
+type Result struct {
+	Lang      string
+	Version   string // 解析后的版本，未安装时与 Requested 相同
+	Requested string // 来源中书写的原始版本
+	Source    string // 来源名称，如 .kver、.tool-versions、.nvmrc
+	File      string // 来源文件路径
+	Installed bool
+}
+
+// ToolVersions 是 asdf 的版本文件名
+const ToolVersions = ".tool-versions"
+
+// asdf 插件名到 kver 语言名的映射
+var toolVersionsNames = map[string]string{
+	"golang": "go",
+	"node":   "nodejs",
+}
+
+// Enabled 判断某个版本来源是否启用，key 为去掉前导点的文件名
+func Enabled(source string) bool {
+	return config.Get().Bool("sources", strings.TrimPrefix(source, "."), true)
+}
+
+// Local 从 dir 向上查找项目级版本配置
+func Local(lang, dir string) (*Result, bool) {
+	p, ok := plugin.Get(lang)
+	if !ok {
+		return nil, false
+	}
+	for _, d := range kverfile.SearchDirs(dir) {
+		// 依赖包目录（如 npm 在 node_modules/<pkg> 中运行安装脚本时）的 package.json
+		// 描述的是依赖自身的要求，不是项目版本
+		if inDependencyDir(d) {
+			continue
+		}
+		if r, ok := lookupDir(p, lang, d); ok {
+			return r, true
+		}
+	}
+	return nil, false
+}
+
+// Shell 返回 kver use 在当前 shell 中设置的版本（KVER_<LANG>_VERSION）
+func Shell(lang string) (*Result, bool) {
+	ver := os.Getenv(state.ShellEnv(lang))
+	if ver == "" {
+		return nil, false
+	}
+	return installed(&Result{Lang: lang, Version: ver, Requested: ver, Source: "shell"}), true
+}
+
+// Global 返回 ~/.kver/global.json 中记录的全局默认版本
+func Global(lang string) (*Result, bool) {
+	s, err := state.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[kver] %v\n", err)
+		return nil, false
+	}
+	ver, ok := s.Get(lang)
+	if !ok {
+		return nil, false
+	}
+	return installed(&Result{Lang: lang, Version: ver, Requested: ver, Source: "global", File: state.Path()}), true
+}
+
+// Current 返回 dir 下某语言实际生效的版本：kver use 设置的 shell 版本优先，
+// 其次项目配置，最后全局默认
+func Current(lang, dir string) (*Result, bool) {
+	if r, ok := Shell(lang); ok {
+		return r, true
+	}
+	if r, ok := Local(lang, dir); ok {
+		return r, true
+	}
+	return Global(lang)
+}
+
+func installed(r *Result) *Result {
+	r.Installed = installer.IsInstalled(r.Lang, r.Version)
+	return r
+}
+
+// lookupDir 按优先级检查单个目录中的各版本来源
+func lookupDir(p plugin.Plugin, lang, dir string) (*Result, bool) {
+	found := dirSources(p, lang, dir, false)
+	if len(found) == 0 {
+		return nil, false
+	}
+	r := found[0]
+	return finish(p, lang, r.Requested, r.Source, r.File), true
+}
+
+// dirSources 按优先级返回目录中写明了该语言版本的来源，all 为 false 时找到第一个即返回。
+// 返回的结果尚未解析为已安装版本
+func dirSources(p plugin.Plugin, lang, dir string, all bool) []*Result {
+	var found []*Result
+	add := func(v, source, file string) bool {
+		found = append(found, &Result{Lang: lang, Version: v, Requested: v, Source: source, File: file})
+		return !all
+	}
+	if f := filepath.Join(dir, kverfile.Name); isFile(f) {
+		if kf, err := kverfile.Load(f); err == nil {
+			if v, ok := kf.Get(lang); ok && add(v, kverfile.Name, f) {
+				return found
+			}
+		}
+	}
+	if f := filepath.Join(dir, ToolVersions); Enabled(ToolVersions) && isFile(f) {
+		if v, ok := parseToolVersions(f, lang); ok && add(v, ToolVersions, f) {
+			return found
+		}
+	}
+	vf, ok := p.(plugin.VersionFiler)
+	if !ok {
+		return found
+	}
+	for _, name := range vf.VersionFiles() {
+		f := filepath.Join(dir, name)
+		if !Enabled(name) || !isFile(f) {
+			continue
+		}
+		v, err := readVersionFile(vf, name, f)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[kver] Ignoring %s: %v\n", f, err)
+			continue
+		}
+		if v != "" && add(v, name, f) {
+			return found
+		}
+	}
+	return found
+}
+
+// readVersionFile 读取并解析插件的版本文件，插件需要引用其他文件时由其自行读取
+func readVersionFile(vf plugin.VersionFiler, name, path string) (string, error) {
+	if r, ok := vf.(plugin.VersionFileReader); ok {
+		return r.ReadVersionFile(path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return vf.ParseVersionFile(name, data)
+}
+
+// Conflicts 返回与 r 同一目录中、要求的版本与 r 不一致的其他来源，
+// 如 .kver 写 3.3.0 而 Gemfile 写 ruby "3.2.2"。shell 和全局版本不检查
+func Conflicts(r *Result) []*Result {
+	p, ok := plugin.Get(r.Lang)
+	if !ok || r.File == "" || r.Source == "global" || !plugin.IsConcrete(r.Version) {
+		return nil
+	}
+	var out []*Result
+	for _, other := range dirSources(p, r.Lang, filepath.Dir(r.File), true) {
+		if other.File != r.File && !plugin.Satisfies(p, other.Requested, r.Version) {
+			out = append(out, other)
+		}
+	}
+	return out
+}
+
+// finish 将来源中的版本解析为已安装的具体版本
+func finish(p plugin.Plugin, lang, requested, source, file string) *Result {
+	r := &Result{Lang: lang, Version: requested, Requested: requested, Source: source, File: file}
+	if v, err := plugin.ResolveInstalled(p, requested); err == nil {
+		r.Version = v
+		r.Installed = true
+	}
+	return r
+}
+
+// parseToolVersions 读取 asdf .tool-versions 中某语言的首选版本
+func parseToolVersions(path, lang string) (string, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", false
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		name := fields[0]
+		if mapped, ok := toolVersionsNames[name]; ok {
+			name = mapped
+		}
+		if name != lang {
+			continue
+		}
+		// 可列出多个备选版本，跳过 kver 无法提供的 system/ref:/path:
+		for _, v := range fields[1:] {
+			if v != "system" && !strings.Contains(v, ":") {
+				return v, true
+			}
+		}
+	}
+	return "", false
+}
+
+// inDependencyDir 判断目录是否位于 node_modules 之内
+func inDependencyDir(dir string) bool {
+	for _, part := range strings.Split(filepath.ToSlash(dir), "/") {
+		if part == "node_modules" {
+			return true
+		}
+	}
+	return false
+}
+
+func isFile(path string) bool {
+	fi, err := os.Stat(path)
+	return err == nil && !fi.IsDir()
+}
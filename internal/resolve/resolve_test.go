@@ -0,0 +1,155 @@
+// Copyright (c) 2025 kk
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package resolve
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"kver/internal/plugin"
+)
+
+// fakePlugin 是测试用的语言插件，版本文件为 .fake-version，已安装版本固定
+type fakePlugin struct{}
+
+func (fakePlugin) Name() string                  { return "fake" }
+func (fakePlugin) Install(string) error          { return nil }
+func (fakePlugin) Uninstall(string) error        { return nil }
+func (fakePlugin) List() ([]string, error)       { return []string{"1.2.3", "1.2.10", "2.0.0"}, nil }
+func (fakePlugin) ListRemote() ([]string, error) { return nil, nil }
+func (fakePlugin) Use(string) error              { return nil }
+func (fakePlugin) Global(string) error           { return nil }
+func (fakePlugin) Local(string, string) error    { return nil }
+func (fakePlugin) VersionFiles() []string        { return []string{".fake-version"} }
+func (fakePlugin) ParseVersionFile(_ string, data []byte) (string, error) {
+	return plugin.FirstToken(data), nil
+}
+
+func init() {
+	plugin.Register("fake", fakePlugin{})
+}
+
+// project 在临时 $HOME 下按 files（相对路径 -> 内容）创建项目目录，返回项目根目录
+func project(t *testing.T, files map[string]string) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("KVER_FAKE_VERSION", "")
+	root := filepath.Join(home, "proj")
+	for name, data := range files {
+		path := filepath.Join(root, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	os.MkdirAll(root, 0755)
+	return root
+}
+
+func TestLocalPrecedence(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   map[string]string
+		dir     string
+		version string
+		source  string
+	}{
+		{
+			".kver first",
+			map[string]string{".kver": "fake = 2.0.0\n", ".tool-versions": "fake 1.2.3\n", ".fake-version": "1.2.10\n"},
+			"", "2.0.0", ".kver",
+		},
+		{
+			".tool-versions before plugin files",
+			map[string]string{".kver": "go = 1.22.1\n", ".tool-versions": "fake 1.2.3\n", ".fake-version": "1.2.10\n"},
+			"", "1.2.3", ".tool-versions",
+		},
+		{
+			"plugin version file",
+			map[string]string{".fake-version": "1.2.10\n"},
+			"", "1.2.10", ".fake-version",
+		},
+		{
+			"nearest directory wins",
+			map[string]string{".kver": "fake = 2.0.0\n", "sub/.fake-version": "1.2.3\n"},
+			"sub", "1.2.3", ".fake-version",
+		},
+		{
+			"walks up to parent",
+			map[string]string{".fake-version": "1.2.3\n", "a/b/README": ""},
+			"a/b", "1.2.3", ".fake-version",
+		},
+		{
+			"partial version resolves to installed",
+			map[string]string{".fake-version": "1.2\n"},
+			"", "1.2.10", ".fake-version",
+		},
+		{
+			"node_modules skipped",
+			map[string]string{".fake-version": "1.2.3\n", "node_modules/dep/.fake-version": "2.0.0\n"},
+			"node_modules/dep", "1.2.3", ".fake-version",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := project(t, tt.files)
+			r, ok := Local("fake", filepath.Join(root, tt.dir))
+			if !ok {
+				t.Fatalf("Local found nothing")
+			}
+			if r.Version != tt.version || r.Source != tt.source || !r.Installed {
+				t.Errorf("Local = %s from %s (installed %v), want %s from %s", r.Version, r.Source, r.Installed, tt.version, tt.source)
+			}
+		})
+	}
+}
+
+func TestLocalNotInstalled(t *testing.T) {
+	root := project(t, map[string]string{".fake-version": "3.1\n"})
+	r, ok := Local("fake", root)
+	if !ok || r.Installed || r.Version != "3.1" || r.Requested != "3.1" {
+		t.Errorf("Local = %+v, %v, want uninstalled 3.1", r, ok)
+	}
+}
+
+func TestCurrentShellFirst(t *testing.T) {
+	root := project(t, map[string]string{".kver": "fake = 2.0.0\n"})
+	t.Setenv("KVER_FAKE_VERSION", "1.2.3")
+	r, ok := Current("fake", root)
+	if !ok || r.Source != "shell" || r.Version != "1.2.3" {
+		t.Errorf("Current = %+v, %v, want shell 1.2.3", r, ok)
+	}
+}
+
+func TestParseToolVersions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ToolVersions)
+	data := strings.Join([]string{
+		"# asdf versions",
+		"golang 1.22.1",
+		"node ref:v20 system 20.12.2 # fallback",
+		"python 3.12.4 3.11.9",
+		"ruby system",
+	}, "\n")
+	os.WriteFile(path, []byte(data), 0644)
+	tests := []struct {
+		lang, want string
+		ok         bool
+	}{
+		{"go", "1.22.1", true},
+		{"nodejs", "20.12.2", true},
+		{"python", "3.12.4", true},
+		{"ruby", "", false},
+		{"java", "", false},
+	}
+	for _, tt := range tests {
+		if v, ok := parseToolVersions(path, tt.lang); v != tt.want || ok != tt.ok {
+			t.Errorf("parseToolVersions(%s) = %q, %v, want %q, %v", tt.lang, v, ok, tt.want, tt.ok)
+		}
+	}
+}
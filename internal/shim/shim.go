@@ -0,0 +1,205 @@
+// Copyright (c) 2025 kk
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+// Package shim 管理 ~/.kver/shims 下的 shim 脚本。
+// 每个已安装版本 bin/ 中的可执行文件对应一个 shim，
+// shim 在调用时通过 kver exec 按当前目录解析版本并执行对应的程序。
+package shim
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"kver/internal/lock"
+	"kver/internal/paths"
+	"kver/internal/plugin"
+	"kver/internal/resolve"
+)
+
+// marker 标识由 kver 生成的 shim，reshim 只会删除带此标记的文件
+const marker = "# kver shim"
+
+// Reshim 根据所有已安装版本重建 shim 目录，并删除已失效的 shim
+func Reshim() error {
+	kverBin, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to locate kver executable: %w", err)
+	}
+	dir := paths.Shims()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	l, err := lock.Acquire("shims", "reshim")
+	if err != nil {
+		return err
+	}
+	defer l.Release()
+	names := map[string]bool{}
+	for lang, p := range plugin.All() {
+		versions, _ := p.List()
+		for _, v := range versions {
+			for _, name := range Executables(lang, v) {
+				names[name] = true
+			}
+		}
+	}
+	for name := range names {
+		script := fmt.Sprintf("#!/bin/sh\n%s\nexec \"%s\" exec \"%s\" \"$@\"\n", marker, kverBin, name)
+		path := filepath.Join(dir, name)
+		if data, err := os.ReadFile(path); err == nil && string(data) == script {
+			continue
+		}
+		if err := os.WriteFile(path, []byte(script), 0755); err != nil {
+			return fmt.Errorf("failed to write shim %s: %w", name, err)
+		}
+	}
+	entries, _ := os.ReadDir(dir)
+	for _, e := range entries {
+		if names[e.Name()] {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		if data, err := os.ReadFile(path); err == nil && strings.Contains(string(data), marker) {
+			os.Remove(path)
+		}
+	}
+	return nil
+}
+
+// Executables 返回某版本 bin/ 下的可执行文件名
+func Executables(lang, version string) []string {
+	binDir := filepath.Join(paths.InstallDir(lang, version), "bin")
+	entries, err := os.ReadDir(binDir)
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, e := range entries {
+		fi, err := os.Stat(filepath.Join(binDir, e.Name()))
+		if err != nil || fi.IsDir() || fi.Mode()&0111 == 0 {
+			continue
+		}
+		names = append(names, e.Name())
+	}
+	return names
+}
+
+// Target 是 shim 解析出的实际可执行文件
+type Target struct {
+	Lang      string
+	Version   string
+	Requested string // 来源中书写的原始版本
+	Path      string
+	Source    string
+}
+
+// Find 按 dir 下生效的版本查找命令 name 对应的可执行文件
+func Find(name, dir string) (*Target, error) {
+	langs := make([]string, 0, len(plugin.All()))
+	for lang := range plugin.All() {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	var candidates []string
+	missing := map[string]*resolve.Result{}
+	for _, lang := range langs {
+		r, ok := resolve.Current(lang, dir)
+		if ok && !r.Installed {
+			missing[lang] = r
+		}
+		if !ok || !r.Installed {
+			continue
+		}
+		path := filepath.Join(paths.InstallDir(lang, r.Version), "bin", name)
+		if fi, err := os.Stat(path); err == nil && !fi.IsDir() {
+			return &Target{Lang: lang, Version: r.Version, Requested: r.Requested, Path: path, Source: r.Source}, nil
+		}
+	}
+	// 没有生效版本提供该命令时，列出哪些已安装版本提供它
+	var unknown []string // 项目要求的版本未安装、且没有任何已安装版本的语言
+	for _, lang := range langs {
+		p, _ := plugin.Get(lang)
+		versions, _ := p.List()
+		if _, ok := missing[lang]; ok && len(versions) == 0 {
+			unknown = append(unknown, lang)
+		}
+		for _, v := range versions {
+			for _, exe := range Executables(lang, v) {
+				if exe != name {
+					continue
+				}
+				// 项目要求的版本未安装时，提示缺少的版本而不是其他已安装版本
+				if r, ok := missing[lang]; ok {
+					return nil, &NotInstalledError{Result: r, Installed: versions}
+				}
+				candidates = append(candidates, lang+" "+v)
+			}
+		}
+	}
+	if len(candidates) > 0 {
+		return nil, fmt.Errorf("%s is not provided by any active version; installed in: %s (set one with kver local/global)", name, strings.Join(candidates, ", "))
+	}
+	// 该语言一个版本都没装时无从得知它提供哪些命令；只有一门这样的语言时认为命令来自它
+	switch len(unknown) {
+	case 0:
+	case 1:
+		return nil, &NotInstalledError{Result: missing[unknown[0]]}
+	default:
+		var reqs []string
+		for _, lang := range unknown {
+			reqs = append(reqs, lang+" "+missing[lang].Requested)
+		}
+		return nil, fmt.Errorf("command not found: %s; required versions not installed: %s (run kver install)", name, strings.Join(reqs, ", "))
+	}
+	return nil, fmt.Errorf("command not found: %s", name)
+}
+
+// NotInstalledError 表示当前目录生效的版本要求（如 pyproject.toml 的 requires-python）没有已安装的版本满足
+type NotInstalledError struct {
+	Result    *resolve.Result
+	Installed []string
+}
+
+func (e *NotInstalledError) Error() string {
+	r := e.Result
+	from := r.Source
+	if r.File != "" {
+		from = r.File
+	}
+	installed := "none"
+	if len(e.Installed) > 0 {
+		installed = strings.Join(e.Installed, ", ")
+	}
+	return fmt.Sprintf("%s %s (from %s) is not installed; installed versions: %s", r.Lang, r.Requested, from, installed)
+}
+
+// Environ 返回执行 t 时的环境变量：将其 bin 目录置于 PATH 最前。shim 目录保留在 PATH 中，
+// 子进程调用其他语言的命令（如 node-gyp 调用 python）时仍按目录解析版本；
+// Find 只返回版本 bin 目录中的真实文件，不会递归回 shim
+func Environ(t *Target) []string {
+	env := []string{}
+	for _, kv := range os.Environ() {
+		if !strings.HasPrefix(kv, "PATH=") {
+			env = append(env, kv)
+		}
+	}
+	path := filepath.Dir(t.Path)
+	if old := os.Getenv("PATH"); old != "" {
+		path += string(os.PathListSeparator) + old
+	}
+	env = append(env, "PATH="+path)
+	if p, ok := plugin.Get(t.Lang); ok {
+		if ep, ok := p.(plugin.EnvProvider); ok {
+			env = append(env, ep.Env(t.Version)...)
+		}
+		if sp, ok := p.(plugin.SourceEnvProvider); ok {
+			env = append(env, sp.SourceEnv(t.Version, t.Requested, t.Source)...)
+		}
+	}
+	return env
+}
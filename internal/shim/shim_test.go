@@ -0,0 +1,38 @@
+// Copyright (c) 2025 kk
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package shim
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"kver/internal/paths"
+)
+
+func TestEnvironPath(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	sep := string(os.PathListSeparator)
+	t.Setenv("PATH", strings.Join([]string{paths.Shims(), "/usr/bin"}, sep))
+	bin := filepath.Join(paths.InstallDir("nodejs", "20.12.2"), "bin")
+	env := Environ(&Target{Lang: "nodejs", Version: "20.12.2", Path: filepath.Join(bin, "node")})
+
+	var path []string
+	for _, kv := range env {
+		if v, ok := strings.CutPrefix(kv, "PATH="); ok {
+			if path != nil {
+				t.Fatalf("PATH set twice")
+			}
+			path = strings.Split(v, sep)
+		}
+	}
+	// 版本的 bin 目录在最前，shim 目录保留，子进程调用其他语言的命令时仍按目录解析
+	want := []string{bin, paths.Shims(), "/usr/bin"}
+	if strings.Join(path, sep) != strings.Join(want, sep) {
+		t.Errorf("PATH = %v, want %v", path, want)
+	}
+}
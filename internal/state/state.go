@@ -0,0 +1,193 @@
+// Copyright (c) 2025 kk
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+// Package state 维护全局默认版本 ~/.kver/global.json，这是全局版本的唯一来源。
+// ~/.kver/env.d/<lang>.sh 由它生成，仅供未使用 kver activate 的 shell 直接 source。
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"kver/internal/lock"
+	"kver/internal/paths"
+	"kver/internal/plugin"
+)
+
+// State 是全局状态文件的内容
+type State struct {
+	Versions map[string]string `json:"versions"`
+}
+
+// Path 返回全局状态文件路径
+func Path() string {
+	return filepath.Join(paths.Home(), "global.json")
+}
+
+// EnvDir 返回生成的 env.d 脚本目录
+func EnvDir() string {
+	return filepath.Join(paths.Home(), "env.d")
+}
+
+// Load 读取全局状态；文件不存在时从旧版 env.d 脚本导入
+func Load() (*State, error) {
+	s := &State{Versions: map[string]string{}}
+	data, err := os.ReadFile(Path())
+	if errors.Is(err, fs.ErrNotExist) {
+		s.importEnvDir()
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", Path(), err)
+	}
+	if s.Versions == nil {
+		s.Versions = map[string]string{}
+	}
+	return s, nil
+}
+
+// Get 返回某语言的全局版本
+func (s *State) Get(lang string) (string, bool) {
+	v, ok := s.Versions[lang]
+	return v, ok && v != ""
+}
+
+// Save 原子地写回状态文件
+func (s *State) Save() error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(paths.Home(), 0755); err != nil {
+		return err
+	}
+	tmp := Path() + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, Path())
+}
+
+// SetGlobal 记录某语言的全局版本并重新生成其 env.d 脚本
+func SetGlobal(lang, version string) error {
+	return update(func(s *State) error {
+		s.Versions[lang] = version
+		if err := s.Save(); err != nil {
+			return err
+		}
+		return writeEnv(lang, version)
+	})
+}
+
+// UnsetGlobal 清除某语言的全局版本及其 env.d 脚本
+func UnsetGlobal(lang string) error {
+	return update(func(s *State) error {
+		return s.unset(lang)
+	})
+}
+
+// UnsetGlobalIf 仅当全局版本等于 version 时清除，用于卸载
+func UnsetGlobalIf(lang, version string) error {
+	return update(func(s *State) error {
+		if v, ok := s.Get(lang); !ok || v != version {
+			return nil
+		}
+		return s.unset(lang)
+	})
+}
+
+// update 在全局锁内读取、修改并写回状态，避免并发的 kver global 互相覆盖
+func update(fn func(s *State) error) error {
+	l, err := lock.Acquire("global", "update "+Path())
+	if err != nil {
+		return err
+	}
+	defer l.Release()
+	s, err := Load()
+	if err != nil {
+		return err
+	}
+	return fn(s)
+}
+
+func (s *State) unset(lang string) error {
+	delete(s.Versions, lang)
+	if err := s.Save(); err != nil {
+		return err
+	}
+	os.Remove(filepath.Join(EnvDir(), lang+".sh"))
+	return nil
+}
+
+// writeEnv 生成 env.d/<lang>.sh
+func writeEnv(lang, version string) error {
+	if err := os.MkdirAll(EnvDir(), 0755); err != nil {
+		return err
+	}
+	script := ActivateShell(lang, version)
+	path := filepath.Join(EnvDir(), lang+".sh")
+	if err := os.WriteFile(path+".tmp", []byte(script), 0644); err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+// ActivateShell 返回激活某语言版本的 shell 片段，插件未实现 ActivateShell 时只设置 PATH
+func ActivateShell(lang, version string) string {
+	if p, ok := plugin.Get(lang); ok {
+		if shell, ok := p.(interface{ ActivateShell(version string) string }); ok {
+			return shell.ActivateShell(version)
+		}
+	}
+	bin := filepath.Join(paths.InstallDir(lang, version), "bin")
+	return fmt.Sprintf("export PATH=\"%s:$PATH\"\n", bin)
+}
+
+// importEnvDir 从旧版 env.d 脚本中的安装路径推断全局版本
+func (s *State) importEnvDir() {
+	entries, err := os.ReadDir(EnvDir())
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		if !strings.HasSuffix(e.Name(), ".sh") {
+			continue
+		}
+		lang := strings.TrimSuffix(e.Name(), ".sh")
+		data, err := os.ReadFile(filepath.Join(EnvDir(), e.Name()))
+		if err != nil {
+			continue
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			// 例: export GOROOT="$HOME/.kver/languages/go/1.21.0"
+			_, rest, ok := strings.Cut(line, "/languages/"+lang+"/")
+			if !ok {
+				continue
+			}
+			if ver, _, _ := strings.Cut(strings.TrimSuffix(rest, `"`), "/"); ver != "" {
+				s.Versions[lang] = ver
+				break
+			}
+		}
+	}
+}
+
+// ShellEnv 返回 kver use 在当前 shell 中记录版本所用的环境变量名，如 KVER_GO_VERSION
+func ShellEnv(lang string) string {
+	return "KVER_" + strings.ToUpper(lang) + "_VERSION"
+}
+
+// UseShell 返回 kver use 输出的 shell 片段，需通过 eval 在当前 shell 中生效
+func UseShell(lang, version string) string {
+	return fmt.Sprintf("export %s=\"%s\"\n", ShellEnv(lang), version) + ActivateShell(lang, version)
+}
@@ -0,0 +1,93 @@
+// Copyright (c) 2025 kk
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestImportEnvDir(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	os.MkdirAll(EnvDir(), 0755)
+	scripts := map[string]string{
+		"go.sh":     "export GOROOT=\"$HOME/.kver/languages/go/1.21.0\"\nexport PATH=\"$GOROOT/bin:$PATH\"\n",
+		"nodejs.sh": "export PATH=\"/home/u/.kver/languages/nodejs/18.16.0/bin:$PATH\"\n",
+		"ruby.sh":   "# empty\n",
+		"README":    "languages/python/3.12.4\n",
+	}
+	for name, data := range scripts {
+		os.WriteFile(filepath.Join(EnvDir(), name), []byte(data), 0644)
+	}
+	s, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"go": "1.21.0", "nodejs": "18.16.0"}
+	if len(s.Versions) != len(want) {
+		t.Errorf("Versions = %v, want %v", s.Versions, want)
+	}
+	for lang, v := range want {
+		if got, ok := s.Get(lang); !ok || got != v {
+			t.Errorf("Get(%s) = %q, %v, want %q", lang, got, ok, v)
+		}
+	}
+}
+
+func TestSetAndUnsetGlobal(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	if err := SetGlobal("go", "1.22.1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetGlobal("nodejs", "20.12.2"); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(Path())
+	if err != nil || !strings.Contains(string(data), `"go": "1.22.1"`) {
+		t.Errorf("global.json = %s, %v", data, err)
+	}
+	script, err := os.ReadFile(filepath.Join(EnvDir(), "go.sh"))
+	if err != nil || !strings.Contains(string(script), filepath.Join("languages", "go", "1.22.1", "bin")) {
+		t.Errorf("env.d/go.sh = %q, %v", script, err)
+	}
+
+	// 只有版本一致时才清除，用于卸载
+	UnsetGlobalIf("go", "1.21.0")
+	if s, _ := Load(); s.Versions["go"] != "1.22.1" {
+		t.Errorf("UnsetGlobalIf with another version removed go: %v", s.Versions)
+	}
+	UnsetGlobalIf("go", "1.22.1")
+	UnsetGlobal("nodejs")
+	s, err := Load()
+	if err != nil || len(s.Versions) != 0 {
+		t.Errorf("after unset: %v, %v", s.Versions, err)
+	}
+	for _, lang := range []string{"go", "nodejs"} {
+		if _, err := os.Stat(filepath.Join(EnvDir(), lang+".sh")); err == nil {
+			t.Errorf("env.d/%s.sh still exists", lang)
+		}
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	os.MkdirAll(filepath.Dir(Path()), 0755)
+	os.WriteFile(Path(), []byte("{"), 0644)
+	if _, err := Load(); err == nil {
+		t.Errorf("Load of invalid global.json should fail")
+	}
+}
+
+func TestUseShell(t *testing.T) {
+	t.Setenv("HOME", "/home/u")
+	got := UseShell("zig", "0.13.0")
+	want := "export KVER_ZIG_VERSION=\"0.13.0\"\nexport PATH=\"/home/u/.kver/languages/zig/0.13.0/bin:$PATH\"\n"
+	if got != want {
+		t.Errorf("UseShell = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,285 @@
+// Copyright (c) 2025 kk
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package version
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Range 是版本范围：多组条件取并集，每组内的条件需同时满足
+type Range struct {
+	sets [][]bound
+}
+
+// bound 是单个比较条件，如 >=18.0.0
+type bound struct {
+	op string // >=、>、<=、<、==、!=，以及前缀匹配 =* 和 !=*（PEP 440 的 ==3.11.*）
+	v  *Version
+}
+
+func (b bound) match(v *Version) bool {
+	switch b.op {
+	case "=*":
+		return hasPrefix(v, b.v)
+	case "!=*":
+		return !hasPrefix(v, b.v)
+	}
+	c := v.Compare(b.v)
+	switch b.op {
+	case ">=":
+		return c >= 0
+	case ">":
+		return c > 0
+	case "<=":
+		return c <= 0
+	case "<":
+		return c < 0
+	case "==":
+		return c == 0
+	case "!=":
+		return c != 0
+	}
+	return false
+}
+
+// Contains 判断版本是否在范围内。预发布版本只在同组条件本身写了预发布版本时才匹配
+func (r *Range) Contains(s string) bool {
+	v, err := Parse(s)
+	if err != nil {
+		return false
+	}
+	for _, set := range r.sets {
+		if v.IsPrerelease() && !hasPrerelease(set) {
+			continue
+		}
+		ok := true
+		for _, b := range set {
+			if !b.match(v) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+// hasPrefix 判断 v 的前几段数字是否与 prefix 相同，如 3.11.4 以 3.11 开头
+func hasPrefix(v, prefix *Version) bool {
+	for i, n := range prefix.Segments {
+		if v.Segment(i) != n {
+			return false
+		}
+	}
+	return true
+}
+
+func hasPrerelease(set []bound) bool {
+	for _, b := range set {
+		if b.v.IsPrerelease() {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseNpmRange 解析 npm semver 范围，如 >=18 <21、^18.2、~20.11、18.x、16 || 18、18 - 20
+func ParseNpmRange(s string) (*Range, error) {
+	r := &Range{}
+	for _, alt := range strings.Split(s, "||") {
+		set, err := parseNpmSet(strings.TrimSpace(alt))
+		if err != nil {
+			return nil, fmt.Errorf("invalid version range %q: %w", s, err)
+		}
+		r.sets = append(r.sets, set)
+	}
+	return r, nil
+}
+
+func parseNpmSet(s string) ([]bound, error) {
+	// 连字符范围：1.2 - 2.3 等价于 >=1.2.0 <2.4.0
+	if lo, hi, ok := strings.Cut(s, " - "); ok {
+		low, err := npmComparator(">=" + strings.TrimSpace(lo))
+		if err != nil {
+			return nil, err
+		}
+		high, err := npmComparator("<=" + strings.TrimSpace(hi))
+		if err != nil {
+			return nil, err
+		}
+		return append(low, high...), nil
+	}
+	var set []bound
+	fields := strings.Fields(s)
+	for i := 0; i < len(fields); i++ {
+		tok := fields[i]
+		// 允许运算符与版本之间有空格，如 >= 18
+		if strings.Trim(tok, "<>=^~") == "" && i+1 < len(fields) {
+			i++
+			tok += fields[i]
+		}
+		b, err := npmComparator(tok)
+		if err != nil {
+			return nil, err
+		}
+		set = append(set, b...)
+	}
+	return set, nil
+}
+
+// npmComparator 将单个比较式展开为上下界，部分版本和 x 通配按 npm 规则补全
+func npmComparator(tok string) ([]bound, error) {
+	op := ""
+	for _, p := range []string{">=", "<=", ">", "<", "=", "^", "~>", "~"} {
+		if strings.HasPrefix(tok, p) {
+			op, tok = p, tok[len(p):]
+			break
+		}
+	}
+	v, n, err := parsePartial(tok)
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		switch op {
+		case ">", "<":
+			// >* 或 <* 不匹配任何版本
+			return []bound{{op: "<", v: &Version{Segments: []int{0}}}}, nil
+		}
+		return nil, nil
+	}
+	switch op {
+	case ">=":
+		return []bound{{">=", v}}, nil
+	case ">":
+		if n < 3 {
+			return []bound{{">=", bump(v, n-1)}}, nil
+		}
+		return []bound{{">", v}}, nil
+	case "<":
+		return []bound{{"<", v}}, nil
+	case "<=":
+		if n < 3 {
+			return []bound{{"<", bump(v, n-1)}}, nil
+		}
+		return []bound{{"<=", v}}, nil
+	case "^":
+		i := 2
+		switch {
+		case v.Segment(0) != 0 || n == 1:
+			i = 0
+		case v.Segment(1) != 0 || n == 2:
+			i = 1
+		}
+		return []bound{{">=", v}, {"<", bump(v, i)}}, nil
+	case "~", "~>":
+		if n == 1 {
+			return []bound{{">=", v}, {"<", bump(v, 0)}}, nil
+		}
+		return []bound{{">=", v}, {"<", bump(v, 1)}}, nil
+	}
+	if n < 3 {
+		return []bound{{">=", v}, {"<", bump(v, n-1)}}, nil
+	}
+	return []bound{{"==", v}}, nil
+}
+
+// parsePartial 解析可能不完整或含 x/* 通配的版本，返回版本和写明的数字段数
+func parsePartial(s string) (*Version, int, error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "v")
+	parts := strings.Split(s, ".")
+	for i, p := range parts {
+		if p == "x" || p == "X" || p == "*" {
+			parts = parts[:i]
+			break
+		}
+	}
+	if len(parts) == 0 || parts[0] == "" {
+		return &Version{}, 0, nil
+	}
+	v, err := Parse(strings.Join(parts, "."))
+	if err != nil {
+		return nil, 0, err
+	}
+	return v, min(len(v.Segments), 3), nil
+}
+
+// bump 返回第 i 段加一、其后各段为零的版本，如 bump(1.2.3, 1) = 1.3.0
+func bump(v *Version, i int) *Version {
+	segs := make([]int, 3)
+	for j := 0; j < i; j++ {
+		segs[j] = v.Segment(j)
+	}
+	segs[i] = v.Segment(i) + 1
+	return &Version{Segments: segs}
+}
+
+// ParsePEP440 解析 PEP 440 版本说明符，如 >=3.10,<3.13、~=3.11、==3.12.*、!=3.11.2
+func ParsePEP440(s string) (*Range, error) {
+	var set []bound
+	for _, spec := range strings.Split(s, ",") {
+		spec = strings.TrimSpace(spec)
+		if spec == "" {
+			continue
+		}
+		b, err := pep440Specifier(spec)
+		if err != nil {
+			return nil, fmt.Errorf("invalid version specifier %q: %w", s, err)
+		}
+		set = append(set, b...)
+	}
+	if len(set) == 0 {
+		return nil, fmt.Errorf("empty version specifier")
+	}
+	return &Range{sets: [][]bound{set}}, nil
+}
+
+func pep440Specifier(spec string) ([]bound, error) {
+	op := ""
+	for _, p := range []string{"===", "~=", "==", "!=", ">=", "<=", ">", "<"} {
+		if strings.HasPrefix(spec, p) {
+			op, spec = p, strings.TrimSpace(spec[len(p):])
+			break
+		}
+	}
+	if op == "" {
+		return nil, fmt.Errorf("missing operator")
+	}
+	wildcard := false
+	if rest, ok := strings.CutSuffix(spec, ".*"); ok {
+		if op != "==" && op != "!=" {
+			return nil, fmt.Errorf(".* is only allowed with == and !=")
+		}
+		spec, wildcard = rest, true
+	}
+	v, err := Parse(spec)
+	if err != nil {
+		return nil, err
+	}
+	switch op {
+	case "==", "===":
+		if wildcard {
+			return []bound{{"=*", v}}, nil
+		}
+		return []bound{{"==", v}}, nil
+	case "!=":
+		if wildcard {
+			return []bound{{"!=*", v}}, nil
+		}
+		return []bound{{"!=", v}}, nil
+	case "~=":
+		// ~=3.10 等价于 >=3.10,==3.*；~=3.10.2 等价于 >=3.10.2,==3.10.*
+		if len(v.Segments) < 2 {
+			return nil, fmt.Errorf("~= requires at least two version segments")
+		}
+		prefix := &Version{Segments: v.Segments[:len(v.Segments)-1]}
+		return []bound{{">=", v}, {"=*", prefix}}, nil
+	}
+	return []bound{{op, v}}, nil
+}
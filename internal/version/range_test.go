@@ -0,0 +1,80 @@
+// Copyright (c) 2025 kk
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package version
+
+import "testing"
+
+type rangeTest struct {
+	rng string
+	in  []string
+	out []string
+}
+
+func checkRange(t *testing.T, parse func(string) (*Range, error), tests []rangeTest) {
+	t.Helper()
+	for _, tt := range tests {
+		r, err := parse(tt.rng)
+		if err != nil {
+			t.Errorf("parse %q: %v", tt.rng, err)
+			continue
+		}
+		for _, v := range tt.in {
+			if !r.Contains(v) {
+				t.Errorf("%q should contain %s", tt.rng, v)
+			}
+		}
+		for _, v := range tt.out {
+			if r.Contains(v) {
+				t.Errorf("%q should not contain %s", tt.rng, v)
+			}
+		}
+	}
+}
+
+func TestParseNpmRange(t *testing.T) {
+	checkRange(t, ParseNpmRange, []rangeTest{
+		{"^18.2", []string{"18.2.0", "18.19.1"}, []string{"18.1.9", "19.0.0", "18.3.0-rc.1"}},
+		{"^0.2.3", []string{"0.2.3", "0.2.9"}, []string{"0.2.2", "0.3.0"}},
+		{"^0.0.3", []string{"0.0.3"}, []string{"0.0.4"}},
+		{"~20.11", []string{"20.11.0", "20.11.1"}, []string{"20.10.9", "20.12.0"}},
+		{"~20", []string{"20.0.0", "20.5.0"}, []string{"21.0.0"}},
+		{"18.x", []string{"18.0.0", "18.20.2"}, []string{"17.9.0", "19.0.0"}},
+		{"18.2.x", []string{"18.2.5"}, []string{"18.3.0"}},
+		{"*", []string{"1.0.0", "22.1.0"}, []string{"22.0.0-rc.1"}},
+		{"16 || 18", []string{"16.20.2", "18.0.0"}, []string{"17.0.0", "20.0.0"}},
+		{"18 - 20", []string{"18.0.0", "20.12.2"}, []string{"17.9.9", "21.0.0"}},
+		{"1.2 - 2.3", []string{"1.2.0", "2.3.9"}, []string{"1.1.9", "2.4.0"}},
+		{">=18 <21", []string{"18.0.0", "20.99.0"}, []string{"17.9.9", "21.0.0"}},
+		{">= 18", []string{"22.0.0"}, []string{"16.0.0"}},
+		{">18", []string{"19.0.0"}, []string{"18.5.0"}},
+		{"<=20", []string{"20.9.9"}, []string{"21.0.0"}},
+		{"=20.11.1", []string{"20.11.1"}, []string{"20.11.0"}},
+		{">=21.0.0-rc.1", []string{"21.0.0-rc.2", "21.0.0"}, []string{"21.0.0-beta.1"}},
+	})
+	for _, s := range []string{"abc", ">=18 || foo", "^18.2.zz"} {
+		if _, err := ParseNpmRange(s); err == nil {
+			t.Errorf("ParseNpmRange(%q) should fail", s)
+		}
+	}
+}
+
+func TestParsePEP440(t *testing.T) {
+	checkRange(t, ParsePEP440, []rangeTest{
+		{">=3.10,<3.13", []string{"3.10.0", "3.12.4"}, []string{"3.9.18", "3.13.0", "3.13.0rc1"}},
+		{"~=3.11", []string{"3.11.0", "3.12.1"}, []string{"3.10.5", "4.0.0"}},
+		{"~=3.11.2", []string{"3.11.2", "3.11.9"}, []string{"3.11.1", "3.12.0"}},
+		{"==3.12.*", []string{"3.12.0", "3.12.7"}, []string{"3.1.2", "3.13.0"}},
+		{"==3.12.4", []string{"3.12.4"}, []string{"3.12.5"}},
+		{">=3.11, !=3.11.2", []string{"3.11.3"}, []string{"3.11.2", "3.10.0"}},
+		{"!=3.11.*", []string{"3.10.9", "3.12.0"}, []string{"3.11.5"}},
+		{">=3.13.0rc1", []string{"3.13.0rc2", "3.13.0"}, []string{"3.13.0b4"}},
+	})
+	for _, s := range []string{"", "3.12", ">=3.*", "~=3", "==abc"} {
+		if _, err := ParsePEP440(s); err == nil {
+			t.Errorf("ParsePEP440(%q) should fail", s)
+		}
+	}
+}
@@ -0,0 +1,215 @@
+// Copyright (c) 2025 kk
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+// Package version 解析并比较各语言使用的版本号格式，例如：
+//
+//	Go:      1.20, 1.21.3, 1.21rc2, 1.22beta1
+//	Python:  3.12.1, 3.13.0a1, 3.13.0rc1
+//	Ruby:    3.3.0, 3.4.0-preview1, 3.3.0-rc1
+//	Node.js: v20.11.0
+package version
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// 预发布阶段，按发布先后排序
+const (
+	Alpha   = "alpha"
+	Beta    = "beta"
+	Preview = "preview"
+	RC      = "rc"
+)
+
+var preRank = map[string]int{Alpha: 1, Beta: 2, Preview: 3, RC: 4, "": 5}
+
+var preAlias = map[string]string{
+	"a": Alpha, "alpha": Alpha,
+	"b": Beta, "beta": Beta,
+	"pre": Preview, "preview": Preview,
+	"c": RC, "rc": RC,
+}
+
+var versionRe = regexp.MustCompile(`^v?([0-9]+(?:\.[0-9]+)*)(?:[-.]?([a-z]+)\.?([0-9]*))?$`)
+
+// Version 是解析后的版本号
+type Version struct {
+	Segments []int  // 数字部分，如 1.21.3 -> [1 21 3]
+	Pre      string // 预发布阶段，正式版为空
+	PreNum   int    // 预发布序号，如 rc2 -> 2
+	Original string // 原始字符串
+}
+
+// Parse 解析版本号，允许带前缀 v
+func Parse(s string) (*Version, error) {
+	m := versionRe.FindStringSubmatch(strings.ToLower(strings.TrimSpace(s)))
+	if m == nil {
+		return nil, fmt.Errorf("invalid version: %q", s)
+	}
+	v := &Version{Original: s}
+	for _, p := range strings.Split(m[1], ".") {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid version: %q", s)
+		}
+		v.Segments = append(v.Segments, n)
+	}
+	if m[2] != "" {
+		pre, ok := preAlias[m[2]]
+		if !ok {
+			return nil, fmt.Errorf("invalid version: %q", s)
+		}
+		v.Pre = pre
+		if m[3] != "" {
+			v.PreNum, _ = strconv.Atoi(m[3])
+		}
+	}
+	return v, nil
+}
+
+// IsPrerelease 是否为 alpha/beta/preview/rc 版本
+func (v *Version) IsPrerelease() bool {
+	return v.Pre != ""
+}
+
+// Segment 返回第 i 个数字段，不存在时为 0
+func (v *Version) Segment(i int) int {
+	if i < len(v.Segments) {
+		return v.Segments[i]
+	}
+	return 0
+}
+
+// Compare 比较两个版本，返回 -1、0 或 1。
+// 缺失的数字段按 0 处理，预发布版本排在对应正式版之前。
+func (v *Version) Compare(o *Version) int {
+	n := max(len(v.Segments), len(o.Segments))
+	for i := 0; i < n; i++ {
+		if c := cmpInt(v.Segment(i), o.Segment(i)); c != 0 {
+			return c
+		}
+	}
+	if c := cmpInt(preRank[v.Pre], preRank[o.Pre]); c != 0 {
+		return c
+	}
+	return cmpInt(v.PreNum, o.PreNum)
+}
+
+// Compare 比较两个版本字符串，无法解析的版本排在最前并按字典序比较
+func Compare(a, b string) int {
+	va, errA := Parse(a)
+	vb, errB := Parse(b)
+	switch {
+	case errA != nil && errB != nil:
+		return strings.Compare(a, b)
+	case errA != nil:
+		return -1
+	case errB != nil:
+		return 1
+	}
+	if c := va.Compare(vb); c != 0 {
+		return c
+	}
+	// 1.20 与 1.20.0 数值相等时，段数少的在前，保证排序稳定
+	if c := cmpInt(len(va.Segments), len(vb.Segments)); c != 0 {
+		return c
+	}
+	return strings.Compare(a, b)
+}
+
+// Sort 按版本号升序排序
+func Sort(versions []string) {
+	sort.SliceStable(versions, func(i, j int) bool {
+		return Compare(versions[i], versions[j]) < 0
+	})
+}
+
+// Latest 返回最高的正式版本，没有正式版本时返回最高的预发布版本
+func Latest(versions []string) string {
+	latest, latestPre := "", ""
+	for _, s := range versions {
+		v, err := Parse(s)
+		if err != nil {
+			continue
+		}
+		if v.IsPrerelease() {
+			if latestPre == "" || Compare(s, latestPre) > 0 {
+				latestPre = s
+			}
+			continue
+		}
+		if latest == "" || Compare(s, latest) > 0 {
+			latest = s
+		}
+	}
+	if latest == "" {
+		return latestPre
+	}
+	return latest
+}
+
+func cmpInt(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// Match 判断 v 是否落在部分版本 prefix 之内，如 1.22 匹配 1.22、1.22.0、1.22.5。
+// 带预发布标识的 prefix 只精确匹配。
+func Match(prefix, v string) bool {
+	p, err := Parse(prefix)
+	if err != nil {
+		return false
+	}
+	pv, err := Parse(v)
+	if err != nil {
+		return false
+	}
+	if p.IsPrerelease() {
+		return p.Compare(pv) == 0 && len(p.Segments) == len(pv.Segments)
+	}
+	if len(pv.Segments) < len(p.Segments) {
+		return false
+	}
+	for i, n := range p.Segments {
+		if pv.Segments[i] != n {
+			return false
+		}
+	}
+	return true
+}
+
+// Resolve 在候选版本中解析查询：latest/stable 取最高正式版，
+// 部分版本取匹配的最高正式版，没有正式版时退而取预发布版
+func Resolve(query string, candidates []string) (string, error) {
+	switch strings.ToLower(query) {
+	case "latest", "stable":
+		if v := Latest(candidates); v != "" {
+			return v, nil
+		}
+		return "", fmt.Errorf("no versions available")
+	}
+	if _, err := Parse(query); err != nil {
+		return "", err
+	}
+	var matched []string
+	for _, c := range candidates {
+		if Match(query, c) {
+			matched = append(matched, c)
+		}
+	}
+	if v := Latest(matched); v != "" {
+		return v, nil
+	}
+	return "", fmt.Errorf("no version matches %s", query)
+}
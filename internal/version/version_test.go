@@ -0,0 +1,136 @@
+// Copyright (c) 2025 kk
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package version
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in       string
+		segments []int
+		pre      string
+		preNum   int
+	}{
+		// Go
+		{"1.21.3", []int{1, 21, 3}, "", 0},
+		{"1.21", []int{1, 21}, "", 0},
+		{"1.21rc2", []int{1, 21}, RC, 2},
+		{"1.22beta1", []int{1, 22}, Beta, 1},
+		// Python
+		{"3.13.0a1", []int{3, 13, 0}, Alpha, 1},
+		{"3.13.0b2", []int{3, 13, 0}, Beta, 2},
+		{"3.13.0rc1", []int{3, 13, 0}, RC, 1},
+		// Ruby
+		{"3.4.0-preview1", []int{3, 4, 0}, Preview, 1},
+		{"3.3.0-rc1", []int{3, 3, 0}, RC, 1},
+		// Node.js
+		{"v20.11.0", []int{20, 11, 0}, "", 0},
+		{"21.0.0-rc.1", []int{21, 0, 0}, RC, 1},
+	}
+	for _, tt := range tests {
+		v, err := Parse(tt.in)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.in, err)
+			continue
+		}
+		if !slices.Equal(v.Segments, tt.segments) || v.Pre != tt.pre || v.PreNum != tt.preNum {
+			t.Errorf("Parse(%q) = %v %q %d, want %v %q %d", tt.in, v.Segments, v.Pre, v.PreNum, tt.segments, tt.pre, tt.preNum)
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	// Ruby 的 patchlevel 由 ruby 插件在解析版本文件时去掉，这里不接受
+	for _, in := range []string{"", "abc", "1..2", "3.2.2p53", "2.7.8-p100", "1.21xyz1"} {
+		if v, err := Parse(in); err == nil {
+			t.Errorf("Parse(%q) = %v, want error", in, v.Segments)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.21.3", "1.21.3", 0},
+		{"1.21.2", "1.21.10", -1},
+		{"1.21rc2", "1.21.0", -1},
+		{"1.21beta1", "1.21rc1", -1},
+		{"1.21rc1", "1.21rc2", -1},
+		{"1.20", "1.20.0", -1},
+		{"3.13.0a2", "3.13.0b1", -1},
+		{"3.13.0rc1", "3.12.9", 1},
+		{"3.4.0-preview2", "3.4.0-rc1", -1},
+		{"3.4.0-rc1", "3.4.0", -1},
+		{"v20.11.0", "20.11.1", -1},
+		{"abc", "1.0.0", -1},
+	}
+	for _, tt := range tests {
+		if got := Compare(tt.a, tt.b); got != tt.want {
+			t.Errorf("Compare(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+		if got := Compare(tt.b, tt.a); got != -tt.want {
+			t.Errorf("Compare(%q, %q) = %d, want %d", tt.b, tt.a, got, -tt.want)
+		}
+	}
+}
+
+func TestSort(t *testing.T) {
+	tests := []struct {
+		name string
+		in   []string
+		want []string
+	}{
+		{
+			"go",
+			[]string{"1.21.0", "1.21rc2", "1.20.14", "1.21beta1", "1.22rc1", "1.21.10", "1.21.2"},
+			[]string{"1.20.14", "1.21beta1", "1.21rc2", "1.21.0", "1.21.2", "1.21.10", "1.22rc1"},
+		},
+		{
+			"python",
+			[]string{"3.13.0", "3.13.0rc1", "3.13.0a1", "3.13.0b2", "3.12.4", "3.13.0b1"},
+			[]string{"3.12.4", "3.13.0a1", "3.13.0b1", "3.13.0b2", "3.13.0rc1", "3.13.0"},
+		},
+		{
+			"ruby",
+			[]string{"3.4.0", "3.4.0-rc1", "3.4.0-preview2", "3.3.6", "3.4.0-preview1"},
+			[]string{"3.3.6", "3.4.0-preview1", "3.4.0-preview2", "3.4.0-rc1", "3.4.0"},
+		},
+	}
+	for _, tt := range tests {
+		got := slices.Clone(tt.in)
+		Sort(got)
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s: Sort = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	candidates := []string{"1.21.0", "1.21.5", "1.22rc1", "1.22.0", "1.23rc1"}
+	tests := []struct {
+		query, want string
+	}{
+		{"1.21", "1.21.5"},
+		{"1.22", "1.22.0"},
+		{"1.23", "1.23rc1"},
+		{"latest", "1.22.0"},
+		{"1.22rc1", "1.22rc1"},
+	}
+	for _, tt := range tests {
+		got, err := Resolve(tt.query, candidates)
+		if err != nil || got != tt.want {
+			t.Errorf("Resolve(%q) = %q, %v, want %q", tt.query, got, err, tt.want)
+		}
+	}
+	if got, err := Resolve("1.24", candidates); err == nil {
+		t.Errorf("Resolve(1.24) = %q, want error", got)
+	}
+}
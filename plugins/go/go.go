@@ -6,18 +6,22 @@
 package goimpl
 
 import (
-	"archive/tar"
-	"bufio"
-	"compress/gzip"
+	"encoding/json"
 	"fmt"
-	"io"
+	"kver/internal/installer"
+	"kver/internal/kverfile"
+	"kver/internal/mirror"
+	"kver/internal/paths"
 	"kver/internal/plugin"
+	"kver/internal/progress"
+	"kver/internal/state"
+	"kver/internal/version"
 	"net/http"
 	"os"
 	"path/filepath"
-	"regexp"
 	"runtime"
-	"sort"
+	"strings"
+	"sync"
 )
 
 type GoPlugin struct{}
@@ -25,146 +29,104 @@ type GoPlugin struct{}
 func (g *GoPlugin) Name() string { return "go" }
 
 func (g *GoPlugin) Install(version string) error {
-	home, _ := os.UserHomeDir()
-	kverHome := filepath.Join(home, ".kver")
-	installDir := filepath.Join(kverHome, "languages", "go", version)
-
-	var installOk bool
-	defer func() {
-		if !installOk {
-			os.RemoveAll(installDir)
-		}
-	}()
-
-	tmpDir, err := os.MkdirTemp("", "kver-go-src-")
-	if err != nil {
-		return fmt.Errorf("failed to create temp dir: %w", err)
-	}
-	defer os.RemoveAll(tmpDir)
-
-	title := func(s string) {
-		fmt.Printf("\n\033[1;36m[kver][go] %s\033[0m\n", s)
-	}
-	sep := func() {
-		fmt.Println("\033[1;34m----------------------------------------\033[0m")
-	}
-
-	title("Step 1/4: Download Go tarball")
-	osStr := runtime.GOOS
-	archStr := runtime.GOARCH
-	goTarName := fmt.Sprintf("go%s.%s-%s.tar.gz", version, osStr, archStr)
-	url := fmt.Sprintf("https://go.dev/dl/%s", goTarName)
-	fmt.Printf("[kver][go] Downloading %s\n", url)
-	resp, err := http.Get(url)
-	if err != nil {
-		return fmt.Errorf("failed to download: %w", err)
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode != 200 {
-		return fmt.Errorf("download failed: %s", resp.Status)
-	}
-	tarball := filepath.Join(tmpDir, goTarName)
-	out, err := os.Create(tarball)
+	a, err := archiveFor(version)
 	if err != nil {
 		return err
 	}
-	if _, err = io.Copy(out, resp.Body); err != nil {
-		out.Close()
-		return err
-	}
-	out.Close()
-	sep()
+	return installer.Run(&installer.Spec{
+		Lang:     "go",
+		Name:     "Go",
+		Version:  version,
+		Artifact: a,
+	})
+}
 
-	title("Step 2/4: Extract Go tarball")
-	if err := g.extractTarGz(tarball, tmpDir); err != nil {
-		return err
+// archiveFor 从下载索引中选出当前平台的安装包。索引不可用时（如离线）按命名规则拼出文件名，
+// 以便复用下载缓存
+func archiveFor(version string) (installer.Artifact, error) {
+	releases, err := fetchReleases()
+	if err != nil {
+		name := fmt.Sprintf("go%s.%s-%s.tar.gz", version, runtime.GOOS, runtime.GOARCH)
+		return installer.Artifact{
+			URL:      mirror.URL("go", name),
+			Strip:    1,
+			Checksum: func() (string, error) { return "", err },
+		}, nil
 	}
-	sep()
-
-	// 查找解压后的 go 目录
-	srcDir := ""
-	dirs, _ := os.ReadDir(tmpDir)
-	for _, d := range dirs {
-		if d.IsDir() && d.Name() == "go" {
-			srcDir = filepath.Join(tmpDir, d.Name())
-			break
+	for _, r := range releases {
+		if r.Version != "go"+version {
+			continue
 		}
+		f, ok := r.archive(runtime.GOOS, runtime.GOARCH)
+		if !ok {
+			return installer.Artifact{}, fmt.Errorf("go %s has no archive for %s/%s", version, runtime.GOOS, runtime.GOARCH)
+		}
+		return installer.Artifact{
+			URL:   mirror.URL("go", f.Filename),
+			Strip: 1,
+			Checksum: func() (string, error) {
+				if f.SHA256 == "" {
+					return "", fmt.Errorf("no sha256 for %s in go release index", f.Filename)
+				}
+				return installer.NewChecksum("sha256", f.SHA256), nil
+			},
+		}, nil
 	}
-	if srcDir == "" {
-		return fmt.Errorf("failed to find extracted go dir")
-	}
+	return installer.Artifact{}, fmt.Errorf("go %s not found in release index", version)
+}
 
-	title("Step 3/4: Move to install directory")
-	os.RemoveAll(installDir)
-	// 确保父目录存在
-	os.MkdirAll(filepath.Dir(installDir), 0755)
-	if err := os.Rename(srcDir, installDir); err != nil {
-		return fmt.Errorf("failed to move go dir: %w", err)
-	}
-	sep()
+// goRelease 是 go.dev/dl/?mode=json 下载索引中的一个版本
+type goRelease struct {
+	Version string   `json:"version"`
+	Stable  bool     `json:"stable"`
+	Files   []goFile `json:"files"`
+}
 
-	// 修复 bin 目录下所有文件为可执行
-	binDir := filepath.Join(installDir, "bin")
-	filepath.Walk(binDir, func(path string, info os.FileInfo, err error) error {
-		if err == nil && !info.IsDir() {
-			os.Chmod(path, 0755)
-		}
-		return nil
-	})
+// goFile 是某个版本的一个下载文件
+type goFile struct {
+	Filename string `json:"filename"`
+	OS       string `json:"os"`
+	Arch     string `json:"arch"`
+	Version  string `json:"version"`
+	SHA256   string `json:"sha256"`
+	Size     int64  `json:"size"`
+	Kind     string `json:"kind"` // archive、installer 或 source
+}
 
-	title(fmt.Sprintf("Step 4/4: Go %s installed successfully!", version))
-	fmt.Printf("[kver][go] Installed at: %s\n", installDir)
-	sep()
-	installOk = true
-	return nil
+// archive 返回指定平台的二进制压缩包（Windows 为 zip，其余为 tar.gz）
+func (r goRelease) archive(goos, goarch string) (goFile, bool) {
+	for _, f := range r.Files {
+		if f.Kind == "archive" && f.OS == goos && f.Arch == goarch {
+			return f, true
+		}
+	}
+	return goFile{}, false
 }
 
-// extractTarGz 解压 tar.gz 包到目标目录
-func (g *GoPlugin) extractTarGz(tarball, dest string) error {
-	f, err := os.Open(tarball)
+// fetchReleases 读取包含所有历史版本的 JSON 下载索引，结果在进程内缓存
+var fetchReleases = sync.OnceValues(func() ([]goRelease, error) {
+	resp, err := http.Get(mirror.URL("go", "?mode=json&include=all"))
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer f.Close()
-	gzr, err := gzip.NewReader(f)
-	if err != nil {
-		return err
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("fetch go release index failed: %s", resp.Status)
 	}
-	defer gzr.Close()
-	tr := tar.NewReader(gzr)
-	for {
-		hdr, err := tr.Next()
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			return err
-		}
-		outPath := filepath.Join(dest, hdr.Name)
-		if hdr.Typeflag == tar.TypeDir {
-			os.MkdirAll(outPath, 0755)
-			continue
-		}
-		os.MkdirAll(filepath.Dir(outPath), 0755)
-		outFile, err := os.Create(outPath)
-		if err != nil {
-			return err
-		}
-		if _, err := io.Copy(outFile, tr); err != nil {
-			outFile.Close()
-			return err
-		}
-		outFile.Close()
+	var releases []goRelease
+	if err := json.NewDecoder(resp.Body).Decode(&releases); err != nil {
+		return nil, fmt.Errorf("invalid go release index: %w", err)
 	}
-	return nil
-}
+	return releases, nil
+})
 
 func (g *GoPlugin) Uninstall(version string) error {
 	home, _ := os.UserHomeDir()
 	kverHome := filepath.Join(home, ".kver")
 	installDir := filepath.Join(kverHome, "languages", "go", version)
-	envFile := filepath.Join(kverHome, "env.d", "go.sh")
-	os.Remove(envFile)
+	if err := state.UnsetGlobalIf("go", version); err != nil {
+		return err
+	}
 	if err := os.RemoveAll(installDir); err != nil {
 		return fmt.Errorf("failed to remove go version: %w", err)
 	}
@@ -173,94 +135,162 @@ func (g *GoPlugin) Uninstall(version string) error {
 }
 
 func (g *GoPlugin) List() ([]string, error) {
-	home, _ := os.UserHomeDir()
-	base := filepath.Join(home, ".kver", "languages", "go")
-	entries, err := os.ReadDir(base)
+	return installer.Installed("go")
+}
+
+// ListRemote 返回索引中的全部版本，包括 1.21rc1 等预发布版本；
+// 部分版本和 latest 仍只解析到正式版
+func (g *GoPlugin) ListRemote() ([]string, error) {
+	releases, err := fetchReleases()
 	if err != nil {
 		return nil, err
 	}
-	var versions []string
-	for _, e := range entries {
-		if e.IsDir() {
-			versions = append(versions, e.Name())
-		}
+	versions := make([]string, 0, len(releases))
+	for _, r := range releases {
+		versions = append(versions, strings.TrimPrefix(r.Version, "go"))
 	}
-	sort.Strings(versions)
+	version.Sort(versions)
 	return versions, nil
 }
 
-func (g *GoPlugin) ListRemote() ([]string, error) {
-	resp, err := http.Get("https://go.dev/dl/")
+// Releases 返回各版本的稳定性，以及当前平台安装包的大小和摘要
+func (g *GoPlugin) Releases() ([]plugin.Release, error) {
+	releases, err := fetchReleases()
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
-	var versions []string
-	scanner := bufio.NewScanner(resp.Body)
-	re := regexp.MustCompile(`go([0-9]+\.[0-9]+\.[0-9]+)\.`)
-	for scanner.Scan() {
-		line := scanner.Text()
-		if m := re.FindStringSubmatch(line); m != nil {
-			versions = append(versions, m[1])
+	out := make([]plugin.Release, 0, len(releases))
+	for _, r := range releases {
+		rel := plugin.Release{Version: strings.TrimPrefix(r.Version, "go"), Unstable: !r.Stable}
+		if f, ok := r.archive(runtime.GOOS, runtime.GOARCH); ok {
+			rel.Extra = append(rel.Extra, fmt.Sprintf("%s %s", f.Filename, progress.FormatBytes(f.Size)))
+			if len(f.SHA256) >= 12 {
+				rel.Extra = append(rel.Extra, "sha256:"+f.SHA256[:12])
+			}
+		} else {
+			rel.Extra = append(rel.Extra, "no archive for "+runtime.GOOS+"/"+runtime.GOARCH)
 		}
+		out = append(out, rel)
 	}
-	// 去重
-	verMap := map[string]struct{}{}
-	for _, v := range versions {
-		verMap[v] = struct{}{}
-	}
-	versions = versions[:0]
-	for v := range verMap {
-		versions = append(versions, v)
-	}
-	sort.Strings(versions)
-	return versions, nil
+	return out, nil
 }
 
 func (g *GoPlugin) Use(version string) error {
-	home, _ := os.UserHomeDir()
-	kverHome := filepath.Join(home, ".kver")
-	installDir := filepath.Join(kverHome, "languages", "go", version)
-	binDir := filepath.Join(installDir, "bin")
-	if _, err := os.Stat(installDir); os.IsNotExist(err) {
+	if !installer.IsInstalled("go", version) {
 		return fmt.Errorf("go version not installed: %s", version)
 	}
-	envDir := filepath.Join(kverHome, "env.d")
-	os.MkdirAll(envDir, 0755)
-	envFile := filepath.Join(envDir, "go.sh")
-	f, err := os.Create(envFile)
-	if err != nil {
-		return err
-	}
-	defer f.Close()
-	fmt.Fprintf(f, "export GOROOT=\"%s\"\n", installDir)
-	fmt.Fprintf(f, "export PATH=\"%s:$PATH\"\n", binDir)
-	fmt.Println("[kver] Now using go", version)
+	// 输出 shell 片段供 eval，提示信息写到 stderr
+	fmt.Print(state.UseShell("go", version))
+	fmt.Fprintln(os.Stderr, "[kver] Now using go", version)
 	return nil
 }
 
 func (g *GoPlugin) Global(version string) error {
-	return g.Use(version)
+	if !installer.IsInstalled("go", version) {
+		return fmt.Errorf("go version not installed: %s", version)
+	}
+	return state.SetGlobal("go", version)
 }
 
 func (g *GoPlugin) Local(version string, projectDir string) error {
-	home, _ := os.UserHomeDir()
-	kverHome := filepath.Join(home, ".kver")
-	installDir := filepath.Join(kverHome, "languages", "go", version)
-	if _, err := os.Stat(installDir); os.IsNotExist(err) {
+	if !installer.IsInstalled("go", version) {
 		return fmt.Errorf("go version not installed: %s", version)
 	}
-	localFile := filepath.Join(projectDir, ".kver")
-	f, err := os.OpenFile(localFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	err := kverfile.Update(filepath.Join(projectDir, kverfile.Name), func(f *kverfile.File) {
+		f.Set("go", version)
+	})
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to write .kver: %w", err)
 	}
-	defer f.Close()
-	fmt.Fprintf(f, "go = %s\n", version)
 	fmt.Println("[kver] Set local go version to", version)
 	return nil
 }
 
+// VersionFiles 返回 Go 插件识别的版本文件，go.work 优先于其中各模块的 go.mod
+func (g *GoPlugin) VersionFiles() []string {
+	return []string{".go-version", "go.work", "go.mod"}
+}
+
+// ParseVersionFile 解析 .go-version（允许写作 go1.22.3）以及 go.mod/go.work 的 toolchain 与 go 指令
+func (g *GoPlugin) ParseVersionFile(name string, data []byte) (string, error) {
+	if name == "go.mod" || name == "go.work" {
+		return parseGoDirectives(data), nil
+	}
+	return strings.TrimPrefix(plugin.FirstToken(data), "go"), nil
+}
+
+// ReadVersionFile 读取版本文件。go.mod 所在模块属于上层 go.work 时忽略 go.mod，
+// 由继续向上查找时的 go.work 决定版本，与 go 命令的工作区行为一致
+func (g *GoPlugin) ReadVersionFile(path string) (string, error) {
+	name := filepath.Base(path)
+	if name == "go.mod" && inWorkspace(filepath.Dir(path)) {
+		return "", nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return g.ParseVersionFile(name, data)
+}
+
+// inWorkspace 判断 dir 或其上层目录（在 kver 的查找范围内）是否有 go.work，GOWORK=off 时不使用工作区
+func inWorkspace(dir string) bool {
+	if os.Getenv("GOWORK") == "off" {
+		return false
+	}
+	for _, d := range kverfile.SearchDirs(dir) {
+		if fi, err := os.Stat(filepath.Join(d, "go.work")); err == nil && !fi.IsDir() {
+			return true
+		}
+	}
+	return false
+}
+
+// parseGoDirectives 返回 toolchain 指令的版本，没有时返回 go 指令的版本。
+// go 1.21 之后 go 指令即最低工具链版本，如 go 1.22.3；1.20 等两段版本按部分版本解析
+func parseGoDirectives(data []byte) string {
+	goVer, toolchain := "", ""
+	for _, line := range strings.Split(string(data), "\n") {
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		switch fields[0] {
+		case "go":
+			goVer = fields[1]
+		case "toolchain":
+			// 如 go1.23.1 或带后缀的 go1.23.1-custom；default 表示不指定
+			if v, ok := strings.CutPrefix(fields[1], "go"); ok {
+				toolchain, _, _ = strings.Cut(v, "-")
+			}
+		}
+	}
+	if toolchain != "" {
+		return toolchain
+	}
+	return goVer
+}
+
+// SourceEnv 在版本来自 go.mod/go.work 且已安装的版本满足其要求时设置 GOTOOLCHAIN=local，
+// 避免 go 命令自行下载工具链。shell、全局版本以及用户已设置 GOTOOLCHAIN 时不干预
+func (g *GoPlugin) SourceEnv(version, requested, source string) []string {
+	if source != "go.mod" && source != "go.work" {
+		return nil
+	}
+	if os.Getenv("GOTOOLCHAIN") != "" || !plugin.Satisfies(g, requested, version) {
+		return nil
+	}
+	return []string{"GOTOOLCHAIN=local"}
+}
+
+// Env 返回 kver exec 运行该版本时设置的环境变量
+func (g *GoPlugin) Env(version string) []string {
+	return []string{"GOROOT=" + paths.InstallDir("go", version)}
+}
+
 func (g *GoPlugin) ActivateShell(version string) string {
 	home, _ := os.UserHomeDir()
 	installDir := filepath.Join(home, ".kver", "languages", "go", version)
@@ -0,0 +1,80 @@
+// Copyright (c) 2025 kk
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package goimpl
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseGoDirectives(t *testing.T) {
+	tests := []struct {
+		data, want string
+	}{
+		{"module example.com/m\n\ngo 1.22.3\n", "1.22.3"},
+		{"module example.com/m\n\ngo 1.20\n", "1.20"},
+		{"module example.com/m\n\ngo 1.21.0\n\ntoolchain go1.23.1\n", "1.23.1"},
+		{"go 1.22\ntoolchain go1.23.1-custom\n", "1.23.1"},
+		{"go 1.22.0\ntoolchain default\n", "1.22.0"},
+		{"go 1.22.0 // 注释\n// go 1.19\n", "1.22.0"},
+		{"go 1.23.0\n\nuse (\n\t./a\n\t./b\n)\n", "1.23.0"},
+		{"module example.com/m\n\nrequire golang.org/x/mod v0.17.0\n", ""},
+	}
+	for _, tt := range tests {
+		if got := parseGoDirectives([]byte(tt.data)); got != tt.want {
+			t.Errorf("parseGoDirectives(%q) = %q, want %q", tt.data, got, tt.want)
+		}
+	}
+}
+
+func TestReadVersionFileWorkspace(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("GOWORK", "")
+	root := filepath.Join(home, "ws")
+	mod := filepath.Join(root, "mod")
+	alone := filepath.Join(home, "alone")
+	for dir, files := range map[string]map[string]string{
+		root:  {"go.work": "go 1.23.0\n\nuse ./mod\n"},
+		mod:   {"go.mod": "module example.com/mod\n\ngo 1.21.0\n"},
+		alone: {"go.mod": "module example.com/alone\n\ngo 1.22.3\n"},
+	} {
+		os.MkdirAll(dir, 0755)
+		for name, data := range files {
+			if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	if !inWorkspace(mod) || inWorkspace(alone) {
+		t.Errorf("inWorkspace(mod) = %v, inWorkspace(alone) = %v, want true, false", inWorkspace(mod), inWorkspace(alone))
+	}
+	g := &GoPlugin{}
+	tests := []struct {
+		path, want string
+	}{
+		// 属于工作区的模块由 go.work 决定版本
+		{filepath.Join(mod, "go.mod"), ""},
+		{filepath.Join(root, "go.work"), "1.23.0"},
+		{filepath.Join(alone, "go.mod"), "1.22.3"},
+	}
+	for _, tt := range tests {
+		if got, err := g.ReadVersionFile(tt.path); err != nil || got != tt.want {
+			t.Errorf("ReadVersionFile(%s) = %q, %v, want %q", tt.path, got, err, tt.want)
+		}
+	}
+
+	// GOWORK=off 时忽略 go.work
+	t.Setenv("GOWORK", "off")
+	if inWorkspace(mod) {
+		t.Errorf("inWorkspace with GOWORK=off = true, want false")
+	}
+	if got, _ := g.ReadVersionFile(filepath.Join(mod, "go.mod")); got != "1.21.0" {
+		t.Errorf("ReadVersionFile(go.mod) with GOWORK=off = %q, want 1.21.0", got)
+	}
+}
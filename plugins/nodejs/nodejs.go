@@ -6,19 +6,25 @@
 package nodejs
 
 import (
-	"archive/tar"
 	"bufio"
-	"compress/gzip"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
 	"path/filepath"
 	"runtime"
-	"sort"
 	"strings"
+	"sync"
 
+	"kver/internal/config"
+	"kver/internal/installer"
+	"kver/internal/kverfile"
+	"kver/internal/mirror"
+	"kver/internal/paths"
 	"kver/internal/plugin"
+	"kver/internal/state"
+	"kver/internal/version"
 )
 
 type NodejsPlugin struct{}
@@ -26,274 +32,313 @@ type NodejsPlugin struct{}
 func (n *NodejsPlugin) Name() string { return "nodejs" }
 
 func (n *NodejsPlugin) Install(version string) error {
-	home, _ := os.UserHomeDir()
-	kverHome := filepath.Join(home, ".kver")
-	installDir := filepath.Join(kverHome, "languages", "nodejs", version)
-
-	var installOk bool
-	defer func() {
-		if !installOk {
-			os.RemoveAll(installDir)
-		}
-	}()
-
-	title := func(s string) {
-		fmt.Printf("\n\033[1;36m[kver][nodejs] %s\033[0m\n", s)
-	}
-	sep := func() {
-		fmt.Println("\033[1;34m----------------------------------------\033[0m")
-	}
-
-	title("Step 1/3: Download Node.js tarball")
-	osStr := runtime.GOOS
-	archStr := runtime.GOARCH
 	var nodeArch string
-	switch archStr {
+	switch runtime.GOARCH {
 	case "amd64":
 		nodeArch = "x64"
 	case "arm64":
 		nodeArch = "arm64"
 	default:
-		return fmt.Errorf("unsupported arch: %s", archStr)
+		return fmt.Errorf("unsupported arch: %s", runtime.GOARCH)
+	}
+	dirName := fmt.Sprintf("node-v%s-%s-%s", version, runtime.GOOS, nodeArch)
+	return installer.Run(&installer.Spec{
+		Lang:    "nodejs",
+		Name:    "Node.js",
+		Version: version,
+		LTS:     ltsCodename(version),
+		Artifact: installer.Artifact{
+			URL:      mirror.URL("nodejs", fmt.Sprintf("v%s/%s.tar.gz", version, dirName)),
+			Strip:    1,
+			Checksum: func() (string, error) { return shasum(version, dirName+".tar.gz") },
+		},
+		PostInstall: []installer.Step{
+			{Title: "Enable corepack", Run: enableCorepack},
+		},
+	})
+}
+
+// enableCorepack 在安装目录的 bin 下生成 pnpm/yarn 入口，由 corepack 按 package.json 的
+// packageManager 字段使用对应版本，无需 npm install -g。
+// Node.js 25 起不再附带 corepack，此时跳过；可通过 [nodejs] corepack = false 关闭
+func enableCorepack(c *installer.Context) error {
+	if !config.Get().Bool("nodejs", "corepack", true) {
+		return nil
+	}
+	bin := filepath.Join(c.StageDir, "bin")
+	if _, err := os.Stat(filepath.Join(bin, "corepack")); err != nil {
+		c.Logf("corepack not bundled with this Node.js, skipped")
+		return nil
+	}
+	cmd := c.Command(filepath.Join(bin, "node"), filepath.Join(bin, "corepack"), "enable", "--install-directory", bin)
+	cmd.Dir = c.StageDir
+	cmd.Env = append(os.Environ(), "PATH="+bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+	if err := cmd.Run(); err != nil {
+		// 不影响 node 本身的使用，只提示
+		c.Logf("corepack enable failed: %v", err)
 	}
-	url := fmt.Sprintf("https://nodejs.org/dist/v%s/node-v%s-%s-%s.tar.gz", version, version, osStr, nodeArch)
-	fmt.Printf("[kver][nodejs] Downloading %s\n", url)
-	resp, err := http.Get(url)
+	return nil
+}
+
+// shasum 从 SHASUMS256.txt 中查找文件的 sha256
+func shasum(version, filename string) (string, error) {
+	resp, err := http.Get(mirror.URL("nodejs", fmt.Sprintf("v%s/SHASUMS256.txt", version)))
 	if err != nil {
-		return fmt.Errorf("failed to download: %w", err)
+		return "", err
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode != 200 {
-		return fmt.Errorf("download failed: %s", resp.Status)
+		return "", fmt.Errorf("fetch SHASUMS256.txt failed: %s", resp.Status)
 	}
-	tarball := filepath.Join(os.TempDir(), filepath.Base(url))
-	out, err := os.Create(tarball)
-	if err != nil {
-		return err
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		// 格式: <sha256>  <filename>
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && fields[1] == filename {
+			return installer.NewChecksum("sha256", fields[0]), nil
+		}
 	}
-	if _, err = io.Copy(out, resp.Body); err != nil {
-		out.Close()
-		return err
+	if err := scanner.Err(); err != nil {
+		return "", err
 	}
-	out.Close()
-	sep()
+	return "", fmt.Errorf("%s not found in SHASUMS256.txt", filename)
+}
 
-	title("Step 2/3: Extract Node.js tarball to install directory")
-	os.RemoveAll(installDir)
-	os.MkdirAll(filepath.Dir(installDir), 0755)
-	if err := extractTarGz(tarball, filepath.Dir(installDir)); err != nil {
+func (n *NodejsPlugin) Uninstall(version string) error {
+	home, _ := os.UserHomeDir()
+	kverHome := filepath.Join(home, ".kver")
+	installDir := filepath.Join(kverHome, "languages", "nodejs", version)
+	if err := state.UnsetGlobalIf("nodejs", version); err != nil {
 		return err
 	}
-	// 解压后目录名如 node-vXX，重命名为 installDir
-	dirs, _ := os.ReadDir(filepath.Dir(installDir))
-	var extractedDir string
-	for _, d := range dirs {
-		if d.IsDir() && strings.HasPrefix(d.Name(), "node-v") {
-			extractedDir = filepath.Join(filepath.Dir(installDir), d.Name())
-			break
-		}
-	}
-	if extractedDir == "" {
-		return fmt.Errorf("failed to find extracted nodejs dir")
-	}
-	if extractedDir != installDir {
-		os.RemoveAll(installDir)
-		if err := os.Rename(extractedDir, installDir); err != nil {
-			return fmt.Errorf("failed to move extracted dir: %w", err)
-		}
+	if err := os.RemoveAll(installDir); err != nil {
+		return fmt.Errorf("failed to remove nodejs version: %w", err)
 	}
-	sep()
-
-	// 修复 bin 目录下所有文件为可执行
-	binDir := filepath.Join(installDir, "bin")
-	filepath.Walk(binDir, func(path string, info os.FileInfo, err error) error {
-		if err == nil && !info.IsDir() {
-			os.Chmod(path, 0755)
-		}
-		return nil
-	})
-
-	title(fmt.Sprintf("Step 3/3: Node.js %s installed successfully!", version))
-	fmt.Printf("[kver][nodejs] Installed at: %s\n", installDir)
-	sep()
-	installOk = true
+	fmt.Println("[kver] Node.js", version, "uninstalled.")
 	return nil
 }
 
-func extractTarGz(tarball, dest string) error {
-	f, err := os.Open(tarball)
+func (n *NodejsPlugin) List() ([]string, error) {
+	return installer.Installed("nodejs")
+}
+
+// releases 读取官方 index.tab 发布索引，结果在进程内缓存
+var releases = sync.OnceValues(func() ([]plugin.Release, error) {
+	resp, err := http.Get(mirror.URL("nodejs", "index.tab"))
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer f.Close()
-	gzr, err := gzip.NewReader(f)
-	if err != nil {
-		return err
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("fetch index.tab failed: %s", resp.Status)
 	}
-	defer gzr.Close()
-	tr := tar.NewReader(gzr)
-	for {
-		hdr, err := tr.Next()
-		if err == io.EOF {
-			break
+	return parseIndex(resp.Body)
+})
+
+// parseIndex 解析 index.tab，保持文件中的顺序
+func parseIndex(r io.Reader) ([]plugin.Release, error) {
+	var out []plugin.Release
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		// 跳过表头 version\tdate...
+		if len(line) < 2 || line[0] != 'v' || line[1] < '0' || line[1] > '9' {
+			continue
 		}
-		if err != nil {
-			return err
+		// 列：version date files npm v8 uv zlib openssl modules lts security
+		fields := strings.Split(line, "\t")
+		for len(fields) < 11 {
+			fields = append(fields, "-")
 		}
-		outPath := filepath.Join(dest, hdr.Name)
-		if hdr.FileInfo().IsDir() {
-			os.MkdirAll(outPath, 0755)
-			continue
+		rel := plugin.Release{
+			Version:  strings.TrimPrefix(fields[0], "v"),
+			Date:     fields[1],
+			Security: fields[10] == "true",
 		}
-		os.MkdirAll(filepath.Dir(outPath), 0755)
-		outFile, err := os.Create(outPath)
-		if err != nil {
-			return err
+		if fields[9] != "-" {
+			rel.LTS = fields[9]
 		}
-		if _, err := io.Copy(outFile, tr); err != nil {
-			outFile.Close()
-			return err
+		if fields[3] != "-" {
+			rel.Extra = append(rel.Extra, "npm "+fields[3])
 		}
-		outFile.Close()
+		out = append(out, rel)
 	}
-	return nil
+	return out, scanner.Err()
 }
 
-// 递归拷贝目录，保留软链和文件内容
-func copyDir(src, dst string) error {
-	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-		rel, err := filepath.Rel(src, path)
-		if err != nil {
-			return err
-		}
-		tgt := filepath.Join(dst, rel)
-		// 先删除目标，防止软链被覆盖成空文件
-		if _, err := os.Lstat(tgt); err == nil {
-			os.Remove(tgt)
-		}
-		if info.Mode()&os.ModeSymlink != 0 {
-			link, err := os.Readlink(path)
-			if err != nil {
-				return err
-			}
-			return os.Symlink(link, tgt)
-		}
-		if info.IsDir() {
-			return os.MkdirAll(tgt, info.Mode())
-		}
-		// 普通文件
-		fSrc, err := os.Open(path)
-		if err != nil {
-			return err
-		}
-		defer fSrc.Close()
-		fDst, err := os.OpenFile(tgt, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode())
-		if err != nil {
-			return err
-		}
-		defer fDst.Close()
-		_, err = io.Copy(fDst, fSrc)
-		return err
-	})
+// Releases 返回全部远程版本及其发布日期、LTS 代号、npm 版本和安全标记
+func (n *NodejsPlugin) Releases() ([]plugin.Release, error) {
+	return releases()
 }
 
-func (n *NodejsPlugin) Uninstall(version string) error {
-	home, _ := os.UserHomeDir()
-	kverHome := filepath.Join(home, ".kver")
-	installDir := filepath.Join(kverHome, "languages", "nodejs", version)
-	envFile := filepath.Join(kverHome, "env.d", "nodejs.sh")
-	os.Remove(envFile)
-	if err := os.RemoveAll(installDir); err != nil {
-		return fmt.Errorf("failed to remove nodejs version: %w", err)
+func (n *NodejsPlugin) ListRemote() ([]string, error) {
+	rs, err := releases()
+	if err != nil {
+		return nil, err
 	}
-	fmt.Println("[kver] Node.js", version, "uninstalled.")
-	return nil
+	versions := make([]string, 0, len(rs))
+	for _, r := range rs {
+		versions = append(versions, r.Version)
+	}
+	// index.tab 按发布时间倒序，统一为版本升序
+	version.Sort(versions)
+	return versions, nil
 }
 
-func (n *NodejsPlugin) List() ([]string, error) {
-	home, _ := os.UserHomeDir()
-	base := filepath.Join(home, ".kver", "languages", "nodejs")
-	entries, err := os.ReadDir(base)
+// ltsAlias 识别 lts、lts/*、latest-lts 与 lts/<codename> 别名，codename 为空表示任意 LTS
+func ltsAlias(alias string) (codename string, ok bool) {
+	alias = strings.ToLower(alias)
+	switch {
+	case alias == "lts" || alias == "lts/*" || alias == "latest-lts":
+		return "", true
+	case strings.HasPrefix(alias, "lts/"):
+		return strings.TrimPrefix(alias, "lts/"), true
+	}
+	return "", false
+}
+
+// ltsCodename 返回版本的 LTS 代号，安装时写入安装清单；索引不可用或不是 LTS 时为空
+func ltsCodename(version string) string {
+	rs, err := releases()
 	if err != nil {
-		return nil, err
+		return ""
+	}
+	for _, r := range rs {
+		if r.Version == version {
+			return r.LTS
+		}
+	}
+	return ""
+}
+
+// AliasVersions 按远程发布索引解析 LTS 别名
+func (n *NodejsPlugin) AliasVersions(alias string) ([]string, bool, error) {
+	codename, ok := ltsAlias(alias)
+	if !ok {
+		return nil, false, nil
+	}
+	rs, err := releases()
+	if err != nil {
+		return nil, true, fmt.Errorf("failed to fetch nodejs release index: %w", err)
 	}
 	var versions []string
-	for _, e := range entries {
-		if e.IsDir() {
-			versions = append(versions, e.Name())
+	for _, r := range rs {
+		if r.LTS == "" {
+			continue
+		}
+		if codename == "" || strings.ToLower(r.LTS) == codename {
+			versions = append(versions, r.Version)
 		}
 	}
-	sort.Strings(versions)
-	return versions, nil
+	if len(versions) == 0 {
+		return nil, true, fmt.Errorf("unknown nodejs lts codename: %s", codename)
+	}
+	return versions, true, nil
 }
 
-func (n *NodejsPlugin) ListRemote() ([]string, error) {
-	resp, err := http.Get("https://nodejs.org/dist/index.tab")
+// InstalledAliasVersions 按安装清单中记录的 LTS 代号解析别名，不访问网络
+func (n *NodejsPlugin) InstalledAliasVersions(alias string) ([]string, bool, error) {
+	codename, ok := ltsAlias(alias)
+	if !ok {
+		return nil, false, nil
+	}
+	installed, err := n.List()
 	if err != nil {
-		return nil, err
+		return nil, true, err
 	}
-	defer resp.Body.Close()
 	var versions []string
-	scanner := bufio.NewScanner(resp.Body)
-	for scanner.Scan() {
-		line := scanner.Text()
-		if strings.HasPrefix(line, "v") {
-			fields := strings.Fields(line)
-			if len(fields) > 0 {
-				versions = append(versions, strings.TrimPrefix(fields[0], "v"))
-			}
+	for _, v := range installed {
+		m, err := installer.ReadManifest("nodejs", v)
+		if err != nil || m.LTS == "" {
+			continue
+		}
+		if codename == "" || strings.ToLower(m.LTS) == codename {
+			versions = append(versions, v)
 		}
 	}
-	return versions, nil
+	return versions, true, nil
 }
 
 func (n *NodejsPlugin) Use(version string) error {
-	home, _ := os.UserHomeDir()
-	kverHome := filepath.Join(home, ".kver")
-	installDir := filepath.Join(kverHome, "languages", "nodejs", version)
-	binDir := filepath.Join(installDir, "bin")
-	if _, err := os.Stat(installDir); os.IsNotExist(err) {
+	if !installer.IsInstalled("nodejs", version) {
 		return fmt.Errorf("nodejs version not installed: %s", version)
 	}
-	envDir := filepath.Join(kverHome, "env.d")
-	os.MkdirAll(envDir, 0755)
-	envFile := filepath.Join(envDir, "nodejs.sh")
-	f, err := os.Create(envFile)
-	if err != nil {
-		return err
-	}
-	defer f.Close()
-	fmt.Fprintf(f, "export NODEJS_HOME=\"%s\"\n", installDir)
-	fmt.Fprintf(f, "export PATH=\"%s:$PATH\"\n", binDir)
-	fmt.Println("[kver] Now using nodejs", version)
+	// 输出 shell 片段供 eval，提示信息写到 stderr
+	fmt.Print(state.UseShell("nodejs", version))
+	fmt.Fprintln(os.Stderr, "[kver] Now using nodejs", version)
 	return nil
 }
 
 func (n *NodejsPlugin) Global(version string) error {
-	return n.Use(version)
+	if !installer.IsInstalled("nodejs", version) {
+		return fmt.Errorf("nodejs version not installed: %s", version)
+	}
+	return state.SetGlobal("nodejs", version)
 }
 
 func (n *NodejsPlugin) Local(version string, projectDir string) error {
-	home, _ := os.UserHomeDir()
-	kverHome := filepath.Join(home, ".kver")
-	installDir := filepath.Join(kverHome, "languages", "nodejs", version)
-	if _, err := os.Stat(installDir); os.IsNotExist(err) {
+	if !installer.IsInstalled("nodejs", version) {
 		return fmt.Errorf("nodejs version not installed: %s", version)
 	}
-	localFile := filepath.Join(projectDir, ".kver")
-	f, err := os.OpenFile(localFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	err := kverfile.Update(filepath.Join(projectDir, kverfile.Name), func(f *kverfile.File) {
+		f.Set("nodejs", version)
+	})
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to write .kver: %w", err)
 	}
-	defer f.Close()
-	fmt.Fprintf(f, "nodejs = %s\n", version)
 	fmt.Println("[kver] Set local nodejs version to", version)
 	return nil
 }
 
+// VersionFiles 返回 Node.js 插件识别的版本文件
+func (n *NodejsPlugin) VersionFiles() []string {
+	return []string{".nvmrc", ".node-version", "package.json"}
+}
+
+// ParseVersionFile 解析 .nvmrc/.node-version，支持 v 前缀及 nvm 的 node、lts/* 写法；
+// package.json 中 volta.node 为精确版本，优先于 engines.node 范围
+func (n *NodejsPlugin) ParseVersionFile(name string, data []byte) (string, error) {
+	if name == "package.json" {
+		var pkg struct {
+			Engines struct {
+				Node string `json:"node"`
+			} `json:"engines"`
+			Volta struct {
+				Node string `json:"node"`
+			} `json:"volta"`
+		}
+		if err := json.Unmarshal(data, &pkg); err != nil {
+			return "", err
+		}
+		if v := strings.TrimSpace(pkg.Volta.Node); v != "" {
+			return strings.TrimPrefix(v, "v"), nil
+		}
+		return strings.TrimSpace(pkg.Engines.Node), nil
+	}
+	v := plugin.FirstToken(data)
+	if v == "node" || v == "stable" {
+		return "latest", nil
+	}
+	return strings.TrimPrefix(v, "v"), nil
+}
+
+// MatchRange 支持 engines.node 的 npm semver 范围，如 >=18 <21、^20.11、18.x
+func (n *NodejsPlugin) MatchRange(query string) (func(string) bool, bool) {
+	if _, err := version.Parse(query); err == nil {
+		return nil, false
+	}
+	r, err := version.ParseNpmRange(query)
+	if err != nil {
+		return nil, false
+	}
+	return r.Contains, true
+}
+
+// Env 返回 kver exec 运行该版本时设置的环境变量
+func (n *NodejsPlugin) Env(version string) []string {
+	return []string{"NODEJS_HOME=" + paths.InstallDir("nodejs", version)}
+}
+
 func (n *NodejsPlugin) ActivateShell(version string) string {
 	home, _ := os.UserHomeDir()
 	installDir := filepath.Join(home, ".kver", "languages", "nodejs", version)
@@ -0,0 +1,82 @@
+// Copyright (c) 2025 kk
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package nodejs
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"kver/internal/plugin"
+)
+
+const index = "version\tdate\tfiles\tnpm\tv8\tuv\tzlib\topenssl\tmodules\tlts\tsecurity\n" +
+	"v22.1.0\t2024-05-02\tlinux-x64\t10.7.0\t12.4.254.14\t1.48.0\t1.3.0.1-motley\t3.0.13+quic\t127\t-\t-\n" +
+	"v20.12.2\t2024-04-10\tlinux-x64\t10.5.0\t11.3.244.8\t1.46.0\t1.3.0.1-motley\t3.0.13+quic\t115\tIron\ttrue\n" +
+	"v0.1.14\t2011-08-26\tsrc\t-\t1.3.0\t-\t-\t-\t-\t-\t-\n" +
+	"v4.0.0\t2015-09-08\n"
+
+func TestParseIndex(t *testing.T) {
+	got, err := parseIndex(strings.NewReader(index))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []plugin.Release{
+		{Version: "22.1.0", Date: "2024-05-02", Extra: []string{"npm 10.7.0"}},
+		{Version: "20.12.2", Date: "2024-04-10", LTS: "Iron", Security: true, Extra: []string{"npm 10.5.0"}},
+		{Version: "0.1.14", Date: "2011-08-26"},
+		// 缺少的列按 - 处理
+		{Version: "4.0.0", Date: "2015-09-08"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseIndex =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestLTSAlias(t *testing.T) {
+	tests := []struct {
+		alias, codename string
+		ok              bool
+	}{
+		{"lts", "", true},
+		{"lts/*", "", true},
+		{"LTS/*", "", true},
+		{"latest-lts", "", true},
+		{"lts/iron", "iron", true},
+		{"lts/Hydrogen", "hydrogen", true},
+		{"latest", "", false},
+		{"20", "", false},
+		{"iron", "", false},
+	}
+	for _, tt := range tests {
+		codename, ok := ltsAlias(tt.alias)
+		if codename != tt.codename || ok != tt.ok {
+			t.Errorf("ltsAlias(%q) = %q, %v, want %q, %v", tt.alias, codename, ok, tt.codename, tt.ok)
+		}
+	}
+}
+
+func TestParsePackageJSON(t *testing.T) {
+	n := &NodejsPlugin{}
+	tests := []struct {
+		data, want string
+	}{
+		{`{"engines": {"node": ">=18 <21"}}`, ">=18 <21"},
+		{`{"engines": {"node": " ^20.11 "}, "volta": {"node": "20.12.2"}}`, "20.12.2"},
+		{`{"volta": {"node": "v18.20.2"}}`, "18.20.2"},
+		{`{"volta": {"npm": "10.5.0"}, "engines": {"node": "18.x"}}`, "18.x"},
+		{`{"name": "app", "engines": {"npm": ">=9"}}`, ""},
+	}
+	for _, tt := range tests {
+		got, err := n.ParseVersionFile("package.json", []byte(tt.data))
+		if err != nil || got != tt.want {
+			t.Errorf("ParseVersionFile(package.json, %s) = %q, %v, want %q", tt.data, got, err, tt.want)
+		}
+	}
+	if got, err := n.ParseVersionFile("package.json", []byte("{")); err == nil {
+		t.Errorf("ParseVersionFile(invalid package.json) = %q, want error", got)
+	}
+}
@@ -6,149 +6,127 @@
 package python
 
 import (
-	"archive/tar"
 	"bufio"
-	"compress/gzip"
+	"encoding/json"
 	"fmt"
-	"io"
-	"io/fs"
+	"kver/internal/buildenv"
+	"kver/internal/installer"
+	"kver/internal/kverfile"
+	"kver/internal/mirror"
+	"kver/internal/paths"
 	"kver/internal/plugin"
+	"kver/internal/state"
+	"kver/internal/version"
 	"net/http"
+	"net/url"
 	"os"
-	"os/exec"
+	"path"
 	"path/filepath"
 	"regexp"
-	"runtime"
-	"sort"
-	"strconv"
 	"strings"
 )
 
 type PythonPlugin struct{}
 
-func (p *PythonPlugin) Name() string { return "python" }
+// openSSL 带上 Python 的 configure 选项名，macOS 上安装提示会给出 brew 的库目录
+var openSSL = buildenv.WithDirOpt(buildenv.OpenSSL, "--with-openssl")
 
-func (p *PythonPlugin) Install(version string) error {
-	home, _ := os.UserHomeDir()
-	kverHome := filepath.Join(home, ".kver")
-	installDir := filepath.Join(kverHome, "languages", "python", version)
+// buildDeps 是编译 Python 需要的库，可选项缺失时对应的标准库模块不可用
+var buildDeps = []buildenv.Dep{
+	openSSL,
+	buildenv.Zlib,
+	buildenv.Libffi,
+	buildenv.Optionally(buildenv.SQLite),
+	buildenv.Optionally(buildenv.Readline),
+	buildenv.Optionally(buildenv.Bzip2),
+	buildenv.Optionally(buildenv.XZ),
+}
 
-	var installOk bool
-	defer func() {
-		if !installOk {
-			os.RemoveAll(installDir)
-		}
-	}()
+// modules 是安装后检查能否导入的扩展模块
+var modules = []buildenv.Module{
+	{Name: "ssl", Dep: openSSL},
+	{Name: "zlib", Dep: buildenv.Zlib},
+	{Name: "_ctypes", Dep: buildenv.Libffi},
+	{Name: "sqlite3", Dep: buildenv.SQLite},
+	{Name: "readline", Dep: buildenv.Readline},
+	{Name: "bz2", Dep: buildenv.Bzip2},
+	{Name: "lzma", Dep: buildenv.XZ},
+}
 
-	tmpDir, err := os.MkdirTemp("", "kver-python-src-")
-	if err != nil {
-		return fmt.Errorf("failed to create temp dir: %w", err)
-	}
-	defer os.RemoveAll(tmpDir)
+// importCheck 逐个导入参数中的模块，输出导入失败的模块名
+const importCheck = `import importlib, sys
+for m in sys.argv[1:]:
+    try:
+        importlib.import_module(m)
+    except Exception:
+        print(m)
+`
 
-	title := func(s string) {
-		fmt.Printf("\n\033[1;36m[kver][python] %s\033[0m\n", s)
-	}
-	sep := func() {
-		fmt.Println("\033[1;34m----------------------------------------\033[0m")
-	}
+func (p *PythonPlugin) Name() string { return "python" }
 
-	title("Step 1/5: Download Python tarball")
-	url := fmt.Sprintf("https://www.python.org/ftp/python/%s/Python-%s.tgz", version, version)
-	fmt.Printf("[kver][python] Downloading %s\n", url)
-	resp, err := http.Get(url)
-	if err != nil {
-		return fmt.Errorf("failed to download: %w", err)
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode != 200 {
-		return fmt.Errorf("download failed: %s", resp.Status)
-	}
-	tarball := filepath.Join(tmpDir, fmt.Sprintf("Python-%s.tgz", version))
-	out, err := os.Create(tarball)
+func (p *PythonPlugin) Install(version string) error {
+	b, err := backend()
 	if err != nil {
 		return err
 	}
-	if _, err = io.Copy(out, resp.Body); err != nil {
-		out.Close()
-		return err
-	}
-	out.Close()
-	sep()
-
-	title("Step 2/5: Extract Python source")
-	if err := p.extractTarGz(tarball, tmpDir); err != nil {
-		return err
-	}
-	sep()
-
-	// 查找解压后的源码目录
-	srcDir := ""
-	dirs, _ := os.ReadDir(tmpDir)
-	for _, d := range dirs {
-		if d.IsDir() && strings.HasPrefix(d.Name(), "Python-") {
-			srcDir = filepath.Join(tmpDir, d.Name())
-			break
-		}
-	}
-	if srcDir == "" {
-		return fmt.Errorf("failed to find extracted python source dir")
-	}
+	if b == backendStandalone {
+		return installStandalone(version)
+	}
+	return installer.Run(&installer.Spec{
+		Lang:    "python",
+		Name:    "Python",
+		Version: version,
+		Backend: backendSource,
+		Artifact: installer.Artifact{
+			URL:      mirror.URL("python", fmt.Sprintf("%s/Python-%s.tgz", version, version)),
+			Strip:    1,
+			Checksum: func() (string, error) { return releaseChecksum(version, fmt.Sprintf("Python-%s.tgz", version)) },
+		},
+		Build: []installer.Step{
+			{Title: "Configure build", Run: func(c *installer.Context) error {
+				if err := c.Command("./configure", c.ConfigureArgs()...).Run(); err != nil {
+					return fmt.Errorf("configure failed: %w", err)
+				}
+				return nil
+			}},
+			{Title: "Compile (make -jN)", Run: func(c *installer.Context) error {
+				if err := c.Command("make", c.MakeArgs()...).Run(); err != nil {
+					return fmt.Errorf("make failed: %w", err)
+				}
+				return nil
+			}},
+			{Title: "Install to target directory", Run: func(c *installer.Context) error {
+				if err := c.Command("make", c.MakeArgs("install", "DESTDIR="+c.DestDir)...).Run(); err != nil {
+					return fmt.Errorf("make install failed: %w", err)
+				}
+				return nil
+			}},
+		},
+		PostInstall: []installer.Step{
+			{Title: "Create python/pip links", Run: linkExecutables},
+		},
+		Preflight: func(c *installer.Context) error {
+			return buildenv.Preflight(c.Logf, buildDeps, buildenv.IncludeFlags(c.Options.CFLAGS, c.Options.ConfigureOpts))
+		},
+		Verify: func(c *installer.Context) error {
+			return buildenv.CheckModules(modules, filepath.Join(c.InstallDir, "bin", "python3"), "-c", importCheck)
+		},
+	})
+}
 
-	// 修正 configure 权限
-	configurePath := filepath.Join(srcDir, "configure")
-	if err := os.Chmod(configurePath, 0755); err != nil {
-		return fmt.Errorf("failed to chmod configure: %w", err)
-	}
-	if err := fixExecPerms(srcDir); err != nil {
-		return fmt.Errorf("failed to fix exec perms: %w", err)
+// linkExecutables 自动补 python/python3/pip/pip3 软链
+func linkExecutables(c *installer.Context) error {
+	binDir := filepath.Join(c.StageDir, "bin")
+	parts := strings.Split(c.Spec.Version, ".")
+	if len(parts) < 2 {
+		return nil
 	}
-
-	title("Step 3/5: Configure build")
-	cmdConf := exec.Command("./configure", "--prefix="+installDir)
-	cmdConf.Dir = srcDir
-	cmdConf.Stdout = os.Stdout
-	cmdConf.Stderr = os.Stderr
-	if err := cmdConf.Run(); err != nil {
-		return fmt.Errorf("configure failed: %w", err)
-	}
-	sep()
-
-	title("Step 4/5: Compile (make -jN)")
-	cpuCount := runtime.NumCPU()
-	makeArgs := []string{"-j" + strconv.Itoa(cpuCount)}
-	cmdMake := exec.Command("make", makeArgs...)
-	cmdMake.Dir = srcDir
-	cmdMake.Stdout = os.Stdout
-	cmdMake.Stderr = os.Stderr
-	if err := cmdMake.Run(); err != nil {
-		return fmt.Errorf("make failed: %w", err)
-	}
-	sep()
-
-	title("Step 5/5: Install to target directory")
-	cmdInstall := exec.Command("make", append(makeArgs, "install")...)
-	cmdInstall.Dir = srcDir
-	cmdInstall.Stdout = os.Stdout
-	cmdInstall.Stderr = os.Stderr
-	if err := cmdInstall.Run(); err != nil {
-		return fmt.Errorf("make install failed: %w", err)
-	}
-	sep()
-
-	// 自动补软链
-	binDir := filepath.Join(installDir, "bin")
-	parts := strings.Split(version, ".")
 	pyMajor := parts[0]
 	pyMinor := parts[1]
 	pyPatch := ""
 	if len(parts) > 2 {
 		pyPatch = parts[2]
 	}
-	pyVer := pyMajor + "." + pyMinor
-	if pyPatch != "" {
-		pyVer = pyVer + "." + pyPatch
-	}
 	pythonExe := "python" + pyMajor + "." + pyMinor
 	if pyPatch != "" {
 		pythonExe = "python" + pyMajor + "." + pyMinor + "." + pyPatch
@@ -182,60 +160,72 @@ func (p *PythonPlugin) Install(version string) error {
 			os.Symlink("pip3", filepath.Join(binDir, "pip"))
 		}
 	}
-
-	title(fmt.Sprintf("Python %s installed successfully!", version))
-	fmt.Printf("[kver][python] Installed at: %s\n", installDir)
-	sep()
-	installOk = true
 	return nil
 }
 
-// extractTarGz 解压 tar.gz 包到目标目录
-func (p *PythonPlugin) extractTarGz(tarball, dest string) error {
-	f, err := os.Open(tarball)
+// releaseChecksum 通过 python.org 下载 API 查找源码包的摘要。
+// 新版本发布 sha256，旧版本只有 md5。镜像不提供该 API，始终访问 python.org。
+func releaseChecksum(version, filename string) (string, error) {
+	var releases []struct {
+		ResourceURI string `json:"resource_uri"`
+	}
+	q := url.Values{"name": {"Python " + version}}
+	if err := getJSON("https://www.python.org/api/v2/downloads/release/?"+q.Encode(), &releases); err != nil {
+		return "", err
+	}
+	if len(releases) == 0 {
+		return "", fmt.Errorf("python %s not found in python.org release API", version)
+	}
+	id := path.Base(strings.TrimSuffix(releases[0].ResourceURI, "/"))
+	var files []struct {
+		URL       string `json:"url"`
+		MD5Sum    string `json:"md5_sum"`
+		SHA256Sum string `json:"sha256_sum"`
+	}
+	if err := getJSON("https://www.python.org/api/v2/downloads/release_file/?release="+url.QueryEscape(id), &files); err != nil {
+		return "", err
+	}
+	for _, f := range files {
+		if path.Base(f.URL) != filename {
+			continue
+		}
+		if f.SHA256Sum != "" {
+			return installer.NewChecksum("sha256", f.SHA256Sum), nil
+		}
+		if f.MD5Sum != "" {
+			return installer.NewChecksum("md5", f.MD5Sum), nil
+		}
+	}
+	return "", fmt.Errorf("no published checksum for %s", filename)
+}
+
+func getJSON(u string, v any) error {
+	req, err := http.NewRequest(http.MethodGet, u, nil)
 	if err != nil {
 		return err
 	}
-	defer f.Close()
-	gzr, err := gzip.NewReader(f)
+	return doJSON(req, v)
+}
+
+func doJSON(req *http.Request, v any) error {
+	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
 		return err
 	}
-	defer gzr.Close()
-	tr := tar.NewReader(gzr)
-	for {
-		hdr, err := tr.Next()
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			return err
-		}
-		outPath := filepath.Join(dest, hdr.Name)
-		if hdr.Typeflag == tar.TypeDir {
-			os.MkdirAll(outPath, 0755)
-			continue
-		}
-		os.MkdirAll(filepath.Dir(outPath), 0755)
-		outFile, err := os.Create(outPath)
-		if err != nil {
-			return err
-		}
-		if _, err := io.Copy(outFile, tr); err != nil {
-			outFile.Close()
-			return err
-		}
-		outFile.Close()
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return fmt.Errorf("fetch %s failed: %s", req.URL, resp.Status)
 	}
-	return nil
+	return json.NewDecoder(resp.Body).Decode(v)
 }
 
 func (p *PythonPlugin) Uninstall(version string) error {
 	home, _ := os.UserHomeDir()
 	kverHome := filepath.Join(home, ".kver")
 	installDir := filepath.Join(kverHome, "languages", "python", version)
-	envFile := filepath.Join(kverHome, "env.d", "python.sh")
-	os.Remove(envFile)
+	if err := state.UnsetGlobalIf("python", version); err != nil {
+		return err
+	}
 	if err := os.RemoveAll(installDir); err != nil {
 		return fmt.Errorf("failed to remove python version: %w", err)
 	}
@@ -244,25 +234,24 @@ func (p *PythonPlugin) Uninstall(version string) error {
 }
 
 func (p *PythonPlugin) List() ([]string, error) {
-	home, _ := os.UserHomeDir()
-	base := filepath.Join(home, ".kver", "languages", "python")
-	entries, err := os.ReadDir(base)
+	return installer.Installed("python")
+}
+
+// ListRemote 返回当前后端可安装的版本
+func (p *PythonPlugin) ListRemote() ([]string, error) {
+	b, err := backend()
 	if err != nil {
 		return nil, err
 	}
-	var versions []string
-	for _, e := range entries {
-		if e.IsDir() {
-			versions = append(versions, e.Name())
-		}
+	if b == backendStandalone {
+		return listStandalone()
 	}
-	sort.Strings(versions)
-	return versions, nil
+	return p.listSource()
 }
 
-func (p *PythonPlugin) ListRemote() ([]string, error) {
-	// 官方页面 https://www.python.org/ftp/python/ 有目录索引
-	resp, err := http.Get("https://www.python.org/ftp/python/")
+func (p *PythonPlugin) listSource() ([]string, error) {
+	// 官方页面 https://www.python.org/ftp/python/ 及其镜像有目录索引
+	resp, err := http.Get(mirror.Base("python"))
 	if err != nil {
 		return nil, err
 	}
@@ -276,77 +265,105 @@ func (p *PythonPlugin) ListRemote() ([]string, error) {
 			versions = append(versions, m[1])
 		}
 	}
-	sort.Strings(versions)
+	version.Sort(versions)
 	return versions, nil
 }
 
 func (p *PythonPlugin) Use(version string) error {
-	home, _ := os.UserHomeDir()
-	kverHome := filepath.Join(home, ".kver")
-	installDir := filepath.Join(kverHome, "languages", "python", version)
-	binDir := filepath.Join(installDir, "bin")
-	if _, err := os.Stat(installDir); os.IsNotExist(err) {
+	if !installer.IsInstalled("python", version) {
 		return fmt.Errorf("python version not installed: %s", version)
 	}
-	envDir := filepath.Join(kverHome, "env.d")
-	os.MkdirAll(envDir, 0755)
-	envFile := filepath.Join(envDir, "python.sh")
-	f, err := os.Create(envFile)
-	if err != nil {
-		return err
-	}
-	defer f.Close()
-	fmt.Fprintf(f, "export PYTHON_HOME=\"%s\"\n", installDir)
-	fmt.Fprintf(f, "export PATH=\"%s:$PATH\"\n", binDir)
-	fmt.Println("[kver] Now using python", version)
+	// 输出 shell 片段供 eval，提示信息写到 stderr
+	fmt.Print(state.UseShell("python", version))
+	fmt.Fprintln(os.Stderr, "[kver] Now using python", version)
 	return nil
 }
 
 func (p *PythonPlugin) Global(version string) error {
-	return p.Use(version)
+	if !installer.IsInstalled("python", version) {
+		return fmt.Errorf("python version not installed: %s", version)
+	}
+	return state.SetGlobal("python", version)
 }
 
 func (p *PythonPlugin) Local(version string, projectDir string) error {
-	home, _ := os.UserHomeDir()
-	kverHome := filepath.Join(home, ".kver")
-	installDir := filepath.Join(kverHome, "languages", "python", version)
-	if _, err := os.Stat(installDir); os.IsNotExist(err) {
+	if !installer.IsInstalled("python", version) {
 		return fmt.Errorf("python version not installed: %s", version)
 	}
-	localFile := filepath.Join(projectDir, ".kver")
-	f, err := os.OpenFile(localFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	err := kverfile.Update(filepath.Join(projectDir, kverfile.Name), func(f *kverfile.File) {
+		f.Set("python", version)
+	})
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to write .kver: %w", err)
 	}
-	defer f.Close()
-	fmt.Fprintf(f, "python = %s\n", version)
 	fmt.Println("[kver] Set local python version to", version)
 	return nil
 }
 
-func (p *PythonPlugin) ActivateShell(version string) string {
-	home, _ := os.UserHomeDir()
-	installDir := filepath.Join(home, ".kver", "languages", "python", version)
-	return fmt.Sprintf("export PYTHON_HOME=\"%s\"\nexport PATH=\"$PYTHON_HOME/bin:$PATH\"\n", installDir)
+// VersionFiles 返回 Python 插件识别的版本文件
+func (p *PythonPlugin) VersionFiles() []string {
+	return []string{".python-version", "runtime.txt", "pyproject.toml"}
 }
 
-func fixExecPerms(root string) error {
-	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
-		if err != nil {
-			return err
+// ParseVersionFile 解析 pyenv 的 .python-version（取第一个非 system 的版本）、
+// Heroku 风格的 runtime.txt（python-3.11.4）以及 pyproject.toml 的 requires-python
+func (p *PythonPlugin) ParseVersionFile(name string, data []byte) (string, error) {
+	if name == "pyproject.toml" {
+		return requiresPython(data), nil
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		v := strings.TrimSpace(line)
+		if v == "" || v == "system" || strings.HasPrefix(v, "#") {
+			continue
 		}
-		if d.IsDir() {
-			return nil
+		return strings.TrimPrefix(v, "python-"), nil
+	}
+	return "", nil
+}
+
+// requiresPython 读取 pyproject.toml 中 [project] 的 requires-python，如 >=3.10,<3.13
+func requiresPython(data []byte) string {
+	section := ""
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "[") {
+			section = strings.Trim(line, "[] ")
+			continue
 		}
-		base := filepath.Base(path)
-		if strings.HasSuffix(path, ".sh") ||
-			strings.HasPrefix(base, "ifchange") ||
-			strings.HasPrefix(base, "configure") ||
-			strings.Contains(path, "/tool/") {
-			return os.Chmod(path, 0755)
+		key, value, ok := strings.Cut(line, "=")
+		if !ok || section != "project" || strings.TrimSpace(key) != "requires-python" {
+			continue
 		}
-		return nil
-	})
+		value = strings.TrimSpace(value)
+		if i := strings.Index(value, "#"); i >= 0 && strings.Count(value[:i], `"`)%2 == 0 && strings.Count(value[:i], "'")%2 == 0 {
+			value = strings.TrimSpace(value[:i])
+		}
+		return strings.Trim(value, `"'`)
+	}
+	return ""
+}
+
+// MatchRange 支持 PEP 440 版本说明符，如 >=3.10,<3.13、~=3.11、==3.12.*
+func (p *PythonPlugin) MatchRange(query string) (func(string) bool, bool) {
+	if _, err := version.Parse(query); err == nil {
+		return nil, false
+	}
+	r, err := version.ParsePEP440(query)
+	if err != nil {
+		return nil, false
+	}
+	return r.Contains, true
+}
+
+// Env 返回 kver exec 运行该版本时设置的环境变量
+func (p *PythonPlugin) Env(version string) []string {
+	return []string{"PYTHON_HOME=" + paths.InstallDir("python", version)}
+}
+
+func (p *PythonPlugin) ActivateShell(version string) string {
+	home, _ := os.UserHomeDir()
+	installDir := filepath.Join(home, ".kver", "languages", "python", version)
+	return fmt.Sprintf("export PYTHON_HOME=\"%s\"\nexport PATH=\"$PYTHON_HOME/bin:$PATH\"\n", installDir)
 }
 
 func init() {
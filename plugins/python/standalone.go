@@ -0,0 +1,283 @@
+// Copyright (c) 2025 kk
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package python
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"kver/internal/buildenv"
+	"kver/internal/cache"
+	"kver/internal/config"
+	"kver/internal/installer"
+	"kver/internal/mirror"
+	"kver/internal/plugin"
+	"kver/internal/version"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// 安装后端：source 从 python.org 源码编译，standalone 使用 python-build-standalone 预编译包
+const (
+	backendSource     = "source"
+	backendStandalone = "standalone"
+)
+
+// Backends 返回 Python 支持的安装后端，默认源码编译
+func (p *PythonPlugin) Backends() []string {
+	return []string{backendSource, backendStandalone}
+}
+
+// backend 返回本次使用的后端：--backend > KVER_PYTHON_BACKEND > config [python] backend > source
+func backend() (string, error) {
+	b := plugin.Backend
+	if b == "" {
+		b = os.Getenv("KVER_PYTHON_BACKEND")
+	}
+	if b == "" {
+		b = config.Get().String("python", "backend", backendSource)
+	}
+	if b != backendSource && b != backendStandalone {
+		return "", fmt.Errorf("unknown python backend %q (available: %s, %s)", b, backendSource, backendStandalone)
+	}
+	return b, nil
+}
+
+// RemoteBackends 返回各远程版本可用的后端，任一后端的索引可用即可
+func (p *PythonPlugin) RemoteBackends() (map[string][]string, error) {
+	out := map[string][]string{}
+	source, srcErr := p.listSource()
+	for _, v := range source {
+		out[v] = append(out[v], backendSource)
+	}
+	builds, sbErr := standaloneBuilds()
+	for v := range builds {
+		out[v] = append(out[v], backendStandalone)
+	}
+	if srcErr != nil && sbErr != nil {
+		return nil, srcErr
+	}
+	return out, nil
+}
+
+// standaloneBuild 是某个 Python 版本在当前平台上最新的预编译包
+type standaloneBuild struct {
+	Version string
+	Tag     string // 发布标签，即构建日期，如 20240713
+	Asset   string
+	Digest  string // GitHub 提供的 sha256:<hex>，旧发布可能为空
+}
+
+// URL 返回预编译包的下载地址
+func (b standaloneBuild) URL() string {
+	return mirror.URL("python_standalone", b.Tag+"/"+url.PathEscape(b.Asset))
+}
+
+// standaloneAsset 匹配 install_only 变体，如 cpython-3.12.4+20240713-x86_64-unknown-linux-gnu-install_only.tar.gz
+var standaloneAsset = regexp.MustCompile(`^cpython-([0-9]+\.[0-9]+\.[0-9]+(?:(?:a|b|rc)[0-9]+)?)\+([0-9]+)-(.+)-install_only\.tar\.gz$`)
+
+// standalonePages 是读取发布索引的最大页数，每页 100 个发布
+const standalonePages = 5
+
+// standaloneBuilds 读取 GitHub 发布索引，返回当前平台各版本最新的构建；结果在进程内缓存
+var standaloneBuilds = sync.OnceValues(func() (map[string]standaloneBuild, error) {
+	triple, err := standaloneTriple()
+	if err != nil {
+		return nil, err
+	}
+	builds := map[string]standaloneBuild{}
+	for page := 1; ; page++ {
+		var releases []struct {
+			TagName string `json:"tag_name"`
+			Assets  []struct {
+				Name   string `json:"name"`
+				Digest string `json:"digest"`
+			} `json:"assets"`
+		}
+		u := fmt.Sprintf("%sreleases?per_page=100&page=%d", mirror.Base("python_standalone_api"), page)
+		if err := getGitHubJSON(u, &releases); err != nil {
+			return nil, err
+		}
+		for _, r := range releases {
+			for _, a := range r.Assets {
+				m := standaloneAsset.FindStringSubmatch(a.Name)
+				if m == nil || m[3] != triple {
+					continue
+				}
+				if cur, ok := builds[m[1]]; ok && cur.Tag >= m[2] {
+					continue
+				}
+				builds[m[1]] = standaloneBuild{Version: m[1], Tag: m[2], Asset: a.Name, Digest: a.Digest}
+			}
+		}
+		if len(releases) < 100 {
+			break
+		}
+		// 超过上限时宁可报错，也不要悄悄漏掉更早的版本
+		if page == standalonePages {
+			return nil, fmt.Errorf("python-build-standalone has more than %d releases, listing truncated", standalonePages*100)
+		}
+	}
+	return builds, nil
+})
+
+// listStandalone 返回当前平台可用的预编译版本
+func listStandalone() ([]string, error) {
+	builds, err := standaloneBuilds()
+	if err != nil {
+		return nil, err
+	}
+	var versions []string
+	for v := range builds {
+		versions = append(versions, v)
+	}
+	version.Sort(versions)
+	return versions, nil
+}
+
+// standaloneTriple 返回当前平台的目标三元组，Alpine 使用 musl 构建
+func standaloneTriple() (string, error) {
+	var arch string
+	switch runtime.GOARCH {
+	case "amd64":
+		arch = "x86_64"
+	case "arm64":
+		arch = "aarch64"
+	default:
+		return "", fmt.Errorf("python-build-standalone: unsupported arch %s", runtime.GOARCH)
+	}
+	switch runtime.GOOS {
+	case "darwin":
+		return arch + "-apple-darwin", nil
+	case "linux":
+		if buildenv.Distro() == buildenv.Alpine {
+			return arch + "-unknown-linux-musl", nil
+		}
+		return arch + "-unknown-linux-gnu", nil
+	}
+	return "", fmt.Errorf("python-build-standalone: unsupported OS %s", runtime.GOOS)
+}
+
+// installStandalone 下载预编译包并安装到与源码构建相同的目录结构
+func installStandalone(ver string) error {
+	var artifact installer.Artifact
+	builds, err := standaloneBuilds()
+	if err != nil {
+		// 离线时使用下载缓存中该版本最近用过的构建，校验和取缓存时记录的值
+		u, ok := cachedStandalone(ver)
+		if !ok {
+			return fmt.Errorf("failed to list python-build-standalone releases: %w", err)
+		}
+		artifact = installer.Artifact{
+			URL:      u,
+			Strip:    1,
+			Checksum: func() (string, error) { return "", err },
+		}
+	} else {
+		b, ok := builds[ver]
+		if !ok {
+			return fmt.Errorf("python-build-standalone has no %s build for this platform (see kver list-remote python)", ver)
+		}
+		artifact = installer.Artifact{
+			URL:      b.URL(),
+			Strip:    1,
+			Checksum: func() (string, error) { return standaloneChecksum(b) },
+		}
+	}
+	return installer.Run(&installer.Spec{
+		Lang:     "python",
+		Name:     "Python",
+		Version:  ver,
+		Backend:  backendStandalone,
+		Artifact: artifact,
+		PostInstall: []installer.Step{
+			{Title: "Create python/pip links", Run: linkExecutables},
+		},
+		Verify: func(c *installer.Context) error {
+			return buildenv.CheckModules(modules, filepath.Join(c.InstallDir, "bin", "python3"), "-c", importCheck)
+		},
+	})
+}
+
+// cachedStandalone 返回下载缓存中该版本在当前平台最近使用的预编译包地址
+func cachedStandalone(ver string) (string, bool) {
+	triple, err := standaloneTriple()
+	if err != nil {
+		return "", false
+	}
+	entries, _ := cache.List()
+	for _, e := range entries {
+		name, err := url.PathUnescape(path.Base(e.URL))
+		if err != nil {
+			continue
+		}
+		m := standaloneAsset.FindStringSubmatch(name)
+		if m == nil || m[1] != ver || m[3] != triple {
+			continue
+		}
+		if _, err := os.Stat(e.File()); err == nil {
+			return e.URL, true
+		}
+	}
+	return "", false
+}
+
+// standaloneChecksum 优先使用 GitHub 提供的摘要，否则读取发布中的 <asset>.sha256 或 SHA256SUMS
+func standaloneChecksum(b standaloneBuild) (string, error) {
+	if algo, hex, ok := strings.Cut(b.Digest, ":"); ok && algo == "sha256" {
+		return installer.NewChecksum(algo, hex), nil
+	}
+	if sum, err := fetchText(b.URL() + ".sha256"); err == nil {
+		if f := strings.Fields(sum); len(f) > 0 {
+			return installer.NewChecksum("sha256", f[0]), nil
+		}
+	}
+	sums, err := fetchText(mirror.URL("python_standalone", b.Tag+"/SHA256SUMS"))
+	if err != nil {
+		return "", err
+	}
+	scanner := bufio.NewScanner(strings.NewReader(sums))
+	for scanner.Scan() {
+		if f := strings.Fields(scanner.Text()); len(f) == 2 && f[1] == b.Asset {
+			return installer.NewChecksum("sha256", f[0]), nil
+		}
+	}
+	return "", fmt.Errorf("no published checksum for %s", b.Asset)
+}
+
+func fetchText(u string) (string, error) {
+	resp, err := http.Get(u)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return "", fmt.Errorf("fetch %s failed: %s", u, resp.Status)
+	}
+	data, err := io.ReadAll(resp.Body)
+	return string(data), err
+}
+
+// getGitHubJSON 请求 GitHub API，设置 GITHUB_TOKEN 时带上认证以提高速率限制。
+// 地址可被镜像配置改写，只有请求 api.github.com 时才发送令牌
+func getGitHubJSON(u string, v any) error {
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	if token := os.Getenv("GITHUB_TOKEN"); token != "" && req.URL.Scheme == "https" && req.URL.Host == "api.github.com" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return doJSON(req, v)
+}
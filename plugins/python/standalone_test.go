@@ -0,0 +1,47 @@
+// Copyright (c) 2025 kk
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package python
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+type recordTransport struct {
+	auth map[string]string
+}
+
+func (rt *recordTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rt.auth[req.URL.String()] = req.Header.Get("Authorization")
+	return &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader("[]")), Request: req}, nil
+}
+
+func TestGetGitHubJSONToken(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", "secret")
+	rt := &recordTransport{auth: map[string]string{}}
+	orig := http.DefaultClient.Transport
+	http.DefaultClient.Transport = rt
+	t.Cleanup(func() { http.DefaultClient.Transport = orig })
+
+	// 镜像地址不应收到令牌
+	tests := map[string]string{
+		"https://api.github.com/repos/astral-sh/python-build-standalone/releases": "Bearer secret",
+		"https://mirror.example.com/repos/releases":                               "",
+		"https://api.github.com.example.com/releases":                             "",
+		"http://api.github.com/releases":                                          "",
+	}
+	for u, want := range tests {
+		var v []any
+		if err := getGitHubJSON(u, &v); err != nil {
+			t.Fatal(err)
+		}
+		if got := rt.auth[u]; got != want {
+			t.Errorf("%s: Authorization = %q, want %q", u, got, want)
+		}
+	}
+}
@@ -6,224 +6,126 @@
 package ruby
 
 import (
-	"archive/tar"
 	"bufio"
-	"compress/gzip"
 	"fmt"
-	"io"
 	"net/http"
 	"os"
-	"os/exec"
+	"path"
 	"path/filepath"
 	"regexp"
-	"runtime"
-	"sort"
-	"strconv"
 	"strings"
 
-	"io/fs"
+	"kver/internal/buildenv"
+	"kver/internal/installer"
+	"kver/internal/kverfile"
+	"kver/internal/mirror"
+	"kver/internal/paths"
 	"kver/internal/plugin"
+	"kver/internal/state"
+	"kver/internal/version"
 )
 
 type RubyPlugin struct{}
 
-func (r *RubyPlugin) Name() string { return "ruby" }
-
-func (r *RubyPlugin) Install(version string) error {
-	home, _ := os.UserHomeDir()
-	kverHome := filepath.Join(home, ".kver")
-	installDir := filepath.Join(kverHome, "languages", "ruby", version)
-
-	var installOk bool
-	defer func() {
-		if !installOk {
-			os.RemoveAll(installDir)
-		}
-	}()
-
-	tmpDir, err := os.MkdirTemp("", "kver-ruby-src-")
-	if err != nil {
-		return fmt.Errorf("failed to create temp dir: %w", err)
-	}
-	defer os.RemoveAll(tmpDir)
-
-	title := func(s string) {
-		fmt.Printf("\n\033[1;36m[kver][ruby] %s\033[0m\n", s)
-	}
-	sep := func() {
-		fmt.Println("\033[1;34m----------------------------------------\033[0m")
-	}
-
-	title("Step 1/5: Download Ruby tarball")
-	majorMinor := version[:strings.LastIndex(version, ".")]
-	url := fmt.Sprintf("https://cache.ruby-lang.org/pub/ruby/%s/ruby-%s.tar.gz", majorMinor, version)
-	fmt.Printf("[kver][ruby] Downloading %s\n", url)
-	resp, err := http.Get(url)
-	if err != nil {
-		return fmt.Errorf("failed to download: %w", err)
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode != 200 {
-		return fmt.Errorf("download failed: %s", resp.Status)
-	}
-	tarball := filepath.Join(tmpDir, fmt.Sprintf("ruby-%s.tar.gz", version))
-	out, err := os.Create(tarball)
-	if err != nil {
-		return err
-	}
-	if _, err = io.Copy(out, resp.Body); err != nil {
-		out.Close()
-		return err
-	}
-	out.Close()
-	sep()
-
-	title("Step 2/5: Extract Ruby source")
-	if err := r.extractTarGz(tarball, tmpDir); err != nil {
-		return err
-	}
-	sep()
-
-	// 查找解压后的源码目录
-	srcDir := ""
-	dirs, _ := os.ReadDir(tmpDir)
-	for _, d := range dirs {
-		if d.IsDir() && strings.HasPrefix(d.Name(), "ruby-") {
-			srcDir = filepath.Join(tmpDir, d.Name())
-			break
-		}
-	}
-	if srcDir == "" {
-		return fmt.Errorf("failed to find extracted ruby source dir")
-	}
-
-	// 修正 configure 权限
-	configurePath := filepath.Join(srcDir, "configure")
-	if err := os.Chmod(configurePath, 0755); err != nil {
-		return fmt.Errorf("failed to chmod configure: %w", err)
-	}
-	// 递归修正源码目录下所有脚本和工具的可执行权限
-	if err := fixExecPerms(srcDir); err != nil {
-		return fmt.Errorf("failed to fix exec perms: %w", err)
-	}
+// openSSL 带上 Ruby 的 configure 选项名，macOS 上安装提示会给出 brew 的库目录
+var openSSL = buildenv.WithDirOpt(buildenv.OpenSSL, "--with-openssl-dir")
 
-	// 只有到这里才创建 installDir（如果 configure/make install 没有自动创建）
-	// 但 Ruby 的 make install 会自动创建，不需要提前创建
+// buildDeps 是编译 Ruby 需要的库，libyaml 缺失时 psych 无法构建，gem 命令不可用
+var buildDeps = []buildenv.Dep{
+	openSSL,
+	buildenv.Zlib,
+	buildenv.LibYAML,
+	buildenv.Optionally(buildenv.Libffi),
+}
 
-	title("Step 3/5: Configure build")
-	cmdConf := exec.Command("./configure", "--prefix="+installDir)
-	cmdConf.Dir = srcDir
-	cmdConf.Stdout = os.Stdout
-	cmdConf.Stderr = os.Stderr
-	if err := cmdConf.Run(); err != nil {
-		return fmt.Errorf("configure failed: %w", err)
-	}
-	sep()
+// modules 是安装后检查能否 require 的扩展库
+var modules = []buildenv.Module{
+	{Name: "openssl", Dep: openSSL},
+	{Name: "zlib", Dep: buildenv.Zlib},
+	{Name: "psych", Dep: buildenv.LibYAML},
+	{Name: "fiddle", Dep: buildenv.Libffi},
+}
 
-	title("Step 4/5: Compile (make -jN)")
-	cpuCount := runtime.NumCPU()
-	makeArgs := []string{"-j" + strconv.Itoa(cpuCount)}
-	cmdMake := exec.Command("make", makeArgs...)
-	cmdMake.Dir = srcDir
-	cmdMake.Stdout = os.Stdout
-	cmdMake.Stderr = os.Stderr
-	if err := cmdMake.Run(); err != nil {
-		return fmt.Errorf("make failed: %w", err)
-	}
-	sep()
+// requireCheck 逐个 require 参数中的库，输出加载失败的库名
+const requireCheck = `ARGV.each { |m| begin; require m; rescue LoadError; puts m; end }`
 
-	title("Step 5/5: Install to target directory")
-	cmdInstall := exec.Command("make", append(makeArgs, "install")...)
-	cmdInstall.Dir = srcDir
-	cmdInstall.Stdout = os.Stdout
-	cmdInstall.Stderr = os.Stderr
-	if err := cmdInstall.Run(); err != nil {
-		return fmt.Errorf("make install failed: %w", err)
-	}
-	sep()
+func (r *RubyPlugin) Name() string { return "ruby" }
 
-	title(fmt.Sprintf("Ruby %s installed successfully!", version))
-	fmt.Printf("[kver][ruby] Installed at: %s\n", installDir)
-	sep()
-	installOk = true
-	return nil
+func (r *RubyPlugin) Install(version string) error {
+	majorMinor := version
+	if i := strings.LastIndex(version, "."); i > 0 {
+		majorMinor = version[:i]
+	}
+	return installer.Run(&installer.Spec{
+		Lang:    "ruby",
+		Name:    "Ruby",
+		Version: version,
+		Artifact: installer.Artifact{
+			URL:      mirror.URL("ruby", fmt.Sprintf("%s/ruby-%s.tar.gz", majorMinor, version)),
+			Strip:    1,
+			Checksum: func() (string, error) { return indexChecksum(fmt.Sprintf("ruby-%s.tar.gz", version)) },
+		},
+		Build: []installer.Step{
+			{Title: "Configure build", Run: func(c *installer.Context) error {
+				// Ruby 的 make install 会自动创建安装目录，不需要提前创建
+				if err := c.Command("./configure", c.ConfigureArgs()...).Run(); err != nil {
+					return fmt.Errorf("configure failed: %w", err)
+				}
+				return nil
+			}},
+			{Title: "Compile (make -jN)", Run: func(c *installer.Context) error {
+				if err := c.Command("make", c.MakeArgs()...).Run(); err != nil {
+					return fmt.Errorf("make failed: %w", err)
+				}
+				return nil
+			}},
+			{Title: "Install to target directory", Run: func(c *installer.Context) error {
+				if err := c.Command("make", c.MakeArgs("install", "DESTDIR="+c.DestDir)...).Run(); err != nil {
+					return fmt.Errorf("make install failed: %w", err)
+				}
+				return nil
+			}},
+		},
+		Preflight: func(c *installer.Context) error {
+			return buildenv.Preflight(c.Logf, buildDeps, buildenv.IncludeFlags(c.Options.CFLAGS, c.Options.ConfigureOpts))
+		},
+		Verify: func(c *installer.Context) error {
+			return buildenv.CheckModules(modules, filepath.Join(c.InstallDir, "bin", "ruby"), "-e", requireCheck)
+		},
+	})
 }
 
-// extractTarGz 解压 tar.gz 包到目标目录
-func (r *RubyPlugin) extractTarGz(tarball, dest string) error {
-	f, err := os.Open(tarball)
+// indexChecksum 从 index.txt 中查找文件的 sha256
+func indexChecksum(filename string) (string, error) {
+	resp, err := http.Get(mirror.URL("ruby", "index.txt"))
 	if err != nil {
-		return err
+		return "", err
 	}
-	defer f.Close()
-	gzr, err := gzip.NewReader(f)
-	if err != nil {
-		return err
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return "", fmt.Errorf("fetch index.txt failed: %s", resp.Status)
 	}
-	defer gzr.Close()
-	tr := tar.NewReader(gzr)
-	for {
-		hdr, err := tr.Next()
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			return err
-		}
-		outPath := filepath.Join(dest, hdr.Name)
-		if hdr.Typeflag == tar.TypeDir {
-			os.MkdirAll(outPath, 0755)
-			continue
-		}
-		os.MkdirAll(filepath.Dir(outPath), 0755)
-		outFile, err := os.Create(outPath)
-		if err != nil {
-			return err
-		}
-		if _, err := io.Copy(outFile, tr); err != nil {
-			outFile.Close()
-			return err
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		// 列：name url sha1 sha256 sha512
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 4 && path.Base(fields[1]) == filename {
+			return installer.NewChecksum("sha256", fields[3]), nil
 		}
-		outFile.Close()
-	}
-	return nil
-}
-
-// buildAndInstallRuby 编译并安装 Ruby 到指定目录
-func (r *RubyPlugin) buildAndInstallRuby(srcDir, prefix string) error {
-	configure := exec.Command("./configure", "--prefix="+prefix)
-	configure.Dir = srcDir
-	configure.Stdout = os.Stdout
-	configure.Stderr = os.Stderr
-	if err := configure.Run(); err != nil {
-		return fmt.Errorf("configure failed: %w", err)
-	}
-
-	cpu := runtime.NumCPU()
-	make := exec.Command("make", fmt.Sprintf("-j%d", cpu))
-	make.Dir = srcDir
-	make.Stdout = os.Stdout
-	make.Stderr = os.Stderr
-	if err := make.Run(); err != nil {
-		return fmt.Errorf("make failed: %w", err)
 	}
-
-	makeInstall := exec.Command("make", "install")
-	makeInstall.Dir = srcDir
-	makeInstall.Stdout = os.Stdout
-	makeInstall.Stderr = os.Stderr
-	if err := makeInstall.Run(); err != nil {
-		return fmt.Errorf("make install failed: %w", err)
+	if err := scanner.Err(); err != nil {
+		return "", err
 	}
-	return nil
+	return "", fmt.Errorf("%s not found in index.txt", filename)
 }
 
 func (r *RubyPlugin) Uninstall(version string) error {
 	home, _ := os.UserHomeDir()
 	kverHome := filepath.Join(home, ".kver")
 	installDir := filepath.Join(kverHome, "languages", "ruby", version)
-	envFile := filepath.Join(kverHome, "env.d", "ruby.sh")
-	os.Remove(envFile)
+	if err := state.UnsetGlobalIf("ruby", version); err != nil {
+		return err
+	}
 	if err := os.RemoveAll(installDir); err != nil {
 		return fmt.Errorf("failed to remove ruby version: %w", err)
 	}
@@ -232,29 +134,20 @@ func (r *RubyPlugin) Uninstall(version string) error {
 }
 
 func (r *RubyPlugin) List() ([]string, error) {
-	home, _ := os.UserHomeDir()
-	base := filepath.Join(home, ".kver", "languages", "ruby")
-	entries, err := os.ReadDir(base)
-	if err != nil {
-		return nil, err
-	}
-	var versions []string
-	for _, e := range entries {
-		if e.IsDir() {
-			versions = append(versions, e.Name())
-		}
-	}
-	return versions, nil
+	return installer.Installed("ruby")
 }
 
 func (r *RubyPlugin) ListRemote() ([]string, error) {
-	resp, err := http.Get("https://cache.ruby-lang.org/pub/ruby/index.txt")
+	resp, err := http.Get(mirror.URL("ruby", "index.txt"))
 	if err != nil {
-		return []string{"3.3.0", "3.2.2", "3.1.4", "2.7.8"}, nil
+		return nil, err
 	}
 	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("fetch ruby index.txt failed: %s", resp.Status)
+	}
 
-	re := regexp.MustCompile(`ruby-([0-9]+\.[0-9]+\.[0-9]+)\.tar\.gz`)
+	re := regexp.MustCompile(`ruby-([0-9]+\.[0-9]+\.[0-9]+(?:-(?:preview|rc)[0-9]+)?)\.tar\.gz`)
 	verMap := make(map[string]struct{})
 	scanner := bufio.NewScanner(resp.Body)
 	for scanner.Scan() {
@@ -264,81 +157,175 @@ func (r *RubyPlugin) ListRemote() ([]string, error) {
 		}
 	}
 	if err := scanner.Err(); err != nil {
-		return []string{"3.3.0", "3.2.2", "3.1.4", "2.7.8"}, nil
+		return nil, err
 	}
 	uniq := make([]string, 0, len(verMap))
 	for v := range verMap {
 		uniq = append(uniq, v)
 	}
-	sort.Strings(uniq)
 	if len(uniq) == 0 {
-		return []string{"3.3.0", "3.2.2", "3.1.4", "2.7.8"}, nil
+		return nil, fmt.Errorf("no ruby versions found in index.txt")
 	}
+	version.Sort(uniq)
 	return uniq, nil
 }
 
 func (r *RubyPlugin) Use(version string) error {
-	home, _ := os.UserHomeDir()
-	kverHome := filepath.Join(home, ".kver")
-	installDir := filepath.Join(kverHome, "languages", "ruby", version)
-	binDir := filepath.Join(installDir, "bin")
-	if _, err := os.Stat(installDir); os.IsNotExist(err) {
+	if !installer.IsInstalled("ruby", version) {
 		return fmt.Errorf("ruby version not installed: %s", version)
 	}
-	envDir := filepath.Join(kverHome, "env.d")
-	os.MkdirAll(envDir, 0755)
-	envFile := filepath.Join(envDir, "ruby.sh")
-	f, err := os.Create(envFile)
-	if err != nil {
-		return err
-	}
-	defer f.Close()
-	fmt.Fprintf(f, "export RUBY_HOME=\"%s\"\n", installDir)
-	fmt.Fprintf(f, "export PATH=\"%s:$PATH\"\n", binDir)
-	fmt.Println("[kver] Now using ruby", version)
+	// 输出 shell 片段供 eval，提示信息写到 stderr
+	fmt.Print(state.UseShell("ruby", version))
+	fmt.Fprintln(os.Stderr, "[kver] Now using ruby", version)
 	return nil
 }
 
 func (r *RubyPlugin) Global(version string) error {
-	return r.Use(version)
+	if !installer.IsInstalled("ruby", version) {
+		return fmt.Errorf("ruby version not installed: %s", version)
+	}
+	return state.SetGlobal("ruby", version)
 }
 
 func (r *RubyPlugin) Local(version string, projectDir string) error {
-	kverFile := filepath.Join(projectDir, ".kver")
-	f, err := os.Create(kverFile)
+	if !installer.IsInstalled("ruby", version) {
+		return fmt.Errorf("ruby version not installed: %s", version)
+	}
+	err := kverfile.Update(filepath.Join(projectDir, kverfile.Name), func(f *kverfile.File) {
+		f.Set("ruby", version)
+	})
 	if err != nil {
 		return fmt.Errorf("failed to write .kver: %w", err)
 	}
-	defer f.Close()
-	f.WriteString(fmt.Sprintf("ruby = %s\n", version))
-	return r.Use(version)
+	fmt.Println("[kver] Set local ruby version to", version)
+	return nil
 }
 
-// ActivateShell 输出 shell 片段用于激活指定 Ruby 版本
-func (r *RubyPlugin) ActivateShell(version string) string {
-	home, _ := os.UserHomeDir()
-	installDir := filepath.Join(home, ".kver", "languages", "ruby", version)
-	return fmt.Sprintf("export RUBY_HOME=\"%s\"\nexport PATH=\"$RUBY_HOME/bin:$PATH\"\n", installDir)
+// VersionFiles 返回 Ruby 插件识别的版本文件，同一目录中 Gemfile 优先于 Gemfile.lock
+func (r *RubyPlugin) VersionFiles() []string {
+	return []string{".ruby-version", "Gemfile", "Gemfile.lock"}
+}
+
+// ParseVersionFile 解析 .ruby-version（允许写作 ruby-3.2.2）、Gemfile 的 ruby 指令和 Gemfile.lock 的 RUBY VERSION
+func (r *RubyPlugin) ParseVersionFile(name string, data []byte) (string, error) {
+	switch name {
+	case "Gemfile":
+		v, _ := gemfileRuby(data)
+		return v, nil
+	case "Gemfile.lock":
+		return lockfileRuby(data), nil
+	}
+	return trimPatchlevel(strings.TrimPrefix(plugin.FirstToken(data), "ruby-")), nil
+}
+
+// ReadVersionFile 在 ParseVersionFile 的基础上支持 Gemfile 中 ruby file: ".ruby-version" 引用的文件
+func (r *RubyPlugin) ReadVersionFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	name := filepath.Base(path)
+	if name != "Gemfile" {
+		return r.ParseVersionFile(name, data)
+	}
+	v, file := gemfileRuby(data)
+	if file == "" {
+		return v, nil
+	}
+	ref, err := os.ReadFile(filepath.Join(filepath.Dir(path), file))
+	if err != nil {
+		return "", fmt.Errorf("ruby file: %w", err)
+	}
+	return r.ParseVersionFile(".ruby-version", ref)
 }
 
-// 修正源码目录下所有可执行文件权限
-func fixExecPerms(root string) error {
-	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
-		if err != nil {
-			return err
+var (
+	// gemfileRubyRe 匹配 Gemfile 中的 ruby 指令，如 ruby "3.2.2"、ruby '~> 3.2.0', engine: "ruby"、ruby file: ".ruby-version"
+	gemfileRubyRe = regexp.MustCompile(`^ruby\b\s*\(?\s*(.*)$`)
+	quotedRe      = regexp.MustCompile(`(\w+:\s*)?["']([^"']*)["']`)
+	patchlevelRe  = regexp.MustCompile(`-?p[0-9]+$`)
+)
+
+// gemfileRuby 返回 Gemfile 中 ruby 指令要求的版本；使用 file: 时返回引用的文件名。
+// 多个条件合并为逗号分隔，如 ruby ">= 3.1", "< 3.4"
+func gemfileRuby(data []byte) (version, file string) {
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		m := gemfileRubyRe.FindStringSubmatch(line)
+		if m == nil {
+			continue
 		}
-		if d.IsDir() {
-			return nil
+		var reqs []string
+		for _, q := range quotedRe.FindAllStringSubmatch(m[1], -1) {
+			switch strings.TrimRight(q[1], ": ") {
+			case "":
+				reqs = append(reqs, trimPatchlevel(strings.TrimSpace(q[2])))
+			case "file":
+				file = q[2]
+			}
 		}
-		base := filepath.Base(path)
-		if strings.HasSuffix(path, ".sh") ||
-			strings.HasPrefix(base, "ifchange") ||
-			strings.HasPrefix(base, "configure") ||
-			strings.Contains(path, "/tool/") {
-			return os.Chmod(path, 0755)
+		return strings.Join(reqs, ", "), file
+	}
+	return "", ""
+}
+
+// lockfileRuby 读取 Gemfile.lock 的 RUBY VERSION 段，如 ruby 3.2.2p53
+func lockfileRuby(data []byte) string {
+	lines := strings.Split(string(data), "\n")
+	for i, line := range lines {
+		if strings.TrimSpace(line) != "RUBY VERSION" || i+1 >= len(lines) {
+			continue
 		}
-		return nil
-	})
+		fields := strings.Fields(lines[i+1])
+		if len(fields) == 2 && fields[0] == "ruby" {
+			return trimPatchlevel(fields[1])
+		}
+	}
+	return ""
+}
+
+// trimPatchlevel 去掉 patchlevel 后缀，如 3.2.2p53、2.7.8-p100
+func trimPatchlevel(v string) string {
+	return patchlevelRe.ReplaceAllString(v, "")
+}
+
+// MatchRange 支持 Gemfile 中的 RubyGems 版本要求，如 ~> 3.2.0、>= 3.1, < 3.4
+func (r *RubyPlugin) MatchRange(query string) (func(string) bool, bool) {
+	if _, err := version.Parse(query); err == nil {
+		return nil, false
+	}
+	// RubyGems 的 ~> 与 PEP 440 的 ~= 含义相同，= 为精确匹配
+	var specs []string
+	for _, req := range strings.Split(query, ",") {
+		req = strings.TrimSpace(req)
+		switch {
+		case strings.HasPrefix(req, "~>"):
+			req = "~=" + strings.TrimSpace(req[2:])
+		case strings.HasPrefix(req, "!="), strings.HasPrefix(req, ">="), strings.HasPrefix(req, "<="):
+		case strings.HasPrefix(req, "="):
+			req = "==" + strings.TrimSpace(req[1:])
+		case req != "" && req[0] >= '0' && req[0] <= '9':
+			req = "==" + req
+		}
+		specs = append(specs, req)
+	}
+	rng, err := version.ParsePEP440(strings.Join(specs, ","))
+	if err != nil {
+		return nil, false
+	}
+	return rng.Contains, true
+}
+
+// Env 返回 kver exec 运行该版本时设置的环境变量
+func (r *RubyPlugin) Env(version string) []string {
+	return []string{"RUBY_HOME=" + paths.InstallDir("ruby", version)}
+}
+
+// ActivateShell 输出 shell 片段用于激活指定 Ruby 版本
+func (r *RubyPlugin) ActivateShell(version string) string {
+	home, _ := os.UserHomeDir()
+	installDir := filepath.Join(home, ".kver", "languages", "ruby", version)
+	return fmt.Sprintf("export RUBY_HOME=\"%s\"\nexport PATH=\"$RUBY_HOME/bin:$PATH\"\n", installDir)
 }
 
 func init() {
@@ -0,0 +1,112 @@
+// Copyright (c) 2025 kk
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package ruby
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGemfileRuby(t *testing.T) {
+	tests := []struct {
+		gemfile, version, file string
+	}{
+		{`ruby "3.2.2"`, "3.2.2", ""},
+		{`ruby '3.2.2'`, "3.2.2", ""},
+		{`ruby "3.2.2p53"`, "3.2.2", ""},
+		{`ruby "~> 3.2.0"`, "~> 3.2.0", ""},
+		{`ruby ">= 3.1", "< 3.4"`, ">= 3.1, < 3.4", ""},
+		{`ruby "3.3.0", engine: "jruby", engine_version: "9.4.5.0"`, "3.3.0", ""},
+		{`ruby("3.1.4")`, "3.1.4", ""},
+		{`ruby file: ".ruby-version"`, "", ".ruby-version"},
+		{"source \"https://rubygems.org\"\n\n  ruby \"3.3.4\"\ngem \"rails\"", "3.3.4", ""},
+		{"source \"https://rubygems.org\"\ngem \"ruby-progressbar\"", "", ""},
+	}
+	for _, tt := range tests {
+		v, file := gemfileRuby([]byte(tt.gemfile))
+		if v != tt.version || file != tt.file {
+			t.Errorf("gemfileRuby(%q) = %q, %q, want %q, %q", tt.gemfile, v, file, tt.version, tt.file)
+		}
+	}
+}
+
+func TestLockfileRuby(t *testing.T) {
+	tests := []struct {
+		lock, want string
+	}{
+		{"GEM\n  specs:\n\nRUBY VERSION\n   ruby 3.2.2p53\n\nBUNDLED WITH\n   2.4.10\n", "3.2.2"},
+		{"RUBY VERSION\n  ruby 2.7.8-p100\n", "2.7.8"},
+		{"RUBY VERSION\n  ruby 3.3.0\n", "3.3.0"},
+		{"GEM\n  specs:\n\nBUNDLED WITH\n   2.4.10\n", ""},
+	}
+	for _, tt := range tests {
+		if got := lockfileRuby([]byte(tt.lock)); got != tt.want {
+			t.Errorf("lockfileRuby(%q) = %q, want %q", tt.lock, got, tt.want)
+		}
+	}
+}
+
+func TestReadVersionFile(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		".ruby-version": "ruby-3.2.2\n",
+		"Gemfile":       "source \"https://rubygems.org\"\nruby file: \".ruby-version\"\n",
+		"Gemfile.lock":  "RUBY VERSION\n   ruby 3.1.4p223\n",
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r := &RubyPlugin{}
+	for name, want := range map[string]string{".ruby-version": "3.2.2", "Gemfile": "3.2.2", "Gemfile.lock": "3.1.4"} {
+		got, err := r.ReadVersionFile(filepath.Join(dir, name))
+		if err != nil || got != want {
+			t.Errorf("ReadVersionFile(%s) = %q, %v, want %q", name, got, err, want)
+		}
+	}
+
+	// file: 引用的文件不存在时报错
+	missing := filepath.Join(t.TempDir(), "Gemfile")
+	os.WriteFile(missing, []byte(`ruby file: ".ruby-version"`), 0644)
+	if got, err := r.ReadVersionFile(missing); err == nil {
+		t.Errorf("ReadVersionFile with missing file: = %q, want error", got)
+	}
+}
+
+func TestMatchRange(t *testing.T) {
+	r := &RubyPlugin{}
+	tests := []struct {
+		query string
+		in    []string
+		out   []string
+	}{
+		{"~> 3.2.0", []string{"3.2.0", "3.2.9"}, []string{"3.3.0", "3.1.9"}},
+		{"~> 3.2", []string{"3.2.0", "3.4.1"}, []string{"4.0.0"}},
+		{">= 3.1, < 3.4", []string{"3.1.0", "3.3.6"}, []string{"3.0.7", "3.4.0"}},
+	}
+	for _, tt := range tests {
+		match, ok := r.MatchRange(tt.query)
+		if !ok {
+			t.Errorf("MatchRange(%q) not recognized", tt.query)
+			continue
+		}
+		for _, v := range tt.in {
+			if !match(v) {
+				t.Errorf("%q should match %s", tt.query, v)
+			}
+		}
+		for _, v := range tt.out {
+			if match(v) {
+				t.Errorf("%q should not match %s", tt.query, v)
+			}
+		}
+	}
+	if _, ok := r.MatchRange("3.2.2"); ok {
+		t.Errorf("MatchRange(3.2.2) should not be treated as a range")
+	}
+}